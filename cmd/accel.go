@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var accelCmd = &cobra.Command{
+	Use:   "accel",
+	Short: "Report GPUs and accelerators with their device nodes and locality",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		accelerators, err := pcieInfo.Accelerators()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDRESS\tKIND\tDRIVER\tNAME\tNUMA\tLOCAL CPUS\tDEVICES")
+		filter, err := getPCIFilter()
+		if err != nil {
+			return err
+		}
+		for _, accelerator := range accelerators {
+			device := accelerator.Device
+			if !filter(device) {
+				continue
+			}
+			name := device.VendorID + ":" + device.DeviceID
+			if device.Names != nil && device.Names.Device != "" {
+				name = device.Names.Device
+			}
+			nodes := strings.Join(accelerator.DeviceNodes(), ",")
+			if nodes == "" {
+				nodes = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", device.Address, accelerator.Kind,
+				device.Driver, name, device.NUMANode, accelerator.LocalCPUs, nodes)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(accelCmd)
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var barsAll bool
+
+var barsCmd = &cobra.Command{
+	Use:   "bars",
+	Short: "Report the BARs of PCIe devices and their resizable BAR support",
+	Long: `Report the BARs of PCIe devices and their resizable BAR support.
+
+Without --all, only memory BARs of endpoints are reported, skipping ROMs,
+I/O ports and bridge windows.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		devices, err := getPCIEDevices(pcieInfo)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDRESS\tBAR\tADDR\tSIZE\tFLAGS\tRESIZABLE")
+		for _, device := range devices {
+			for _, bar := range device.BARs {
+				if !barsAll && (bar.Type != pcieinfo.MemoryBAR || !strings.HasPrefix(bar.Name, "BAR")) {
+					continue
+				}
+				flags := []string{string(bar.Type)}
+				if bar.Is64Bit {
+					flags = append(flags, "64bit")
+				}
+				if bar.Prefetchable {
+					flags = append(flags, "prefetchable")
+				}
+				if bar.Size > pcieinfo.LargeBARSize && bar.Type == pcieinfo.MemoryBAR {
+					flags = append(flags, "large")
+				}
+				resizable := "-"
+				if bar.Resizable() {
+					resizable = formatBinarySize(bar.ResizableSizes[0]) + "-" + formatBinarySize(bar.MaxResizableSize())
+				}
+				fmt.Fprintf(w, "%s\t%s\t0x%x\t%s\t%s\t%s\n", device.Address, bar.Name, bar.Address, formatBinarySize(bar.Size), strings.Join(flags, ","), resizable)
+			}
+		}
+		return w.Flush()
+	},
+}
+
+// formatBinarySize formats a power of two size, such as a BAR, in binary
+// units.
+func formatBinarySize(size uint64) string {
+	units := []string{"B", "K", "M", "G", "T", "P"}
+	unit := 0
+	for size >= 1024 && size%1024 == 0 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%d%s", size, units[unit])
+}
+
+func init() {
+	barsCmd.Flags().BoolVar(&barsAll, "all", false, "Also report ROMs, I/O ports, VF BARs and bridge windows")
+	rootCmd.AddCommand(barsCmd)
+}
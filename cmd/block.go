@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var blockCmd = &cobra.Command{
+	Use:   "block [DEVICE|PATH...]",
+	Short: "Report the NUMA locality of NVMe drives and block devices",
+	Long: `Report the NUMA locality of NVMe drives and block devices.
+
+Without arguments, every NVMe namespace is listed. Otherwise each argument is
+a block device (e.g. nvme0n1 or /dev/sda1) or a path on a mounted filesystem
+(e.g. /scratch), resolved to the PCIe devices backing it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		if len(args) == 0 {
+			devices, err := getPCIEDevices(pcieInfo)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, "BLOCK\tCONTROLLER\tADDRESS\tMODEL\tSIZE\tNUMA\tLOCAL CPUS")
+			for _, device := range devices {
+				if device.NVMe == nil {
+					continue
+				}
+				localCpus, err := device.LocalCPUs()
+				if err != nil {
+					return err
+				}
+				for _, namespace := range device.NVMe.Namespaces {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", namespace.BlockDevice, device.NVMe.Name,
+						device.Address, device.NVMe.Model, formatBytes(namespace.SizeBytes), device.NUMANode, localCpus)
+				}
+			}
+			return w.Flush()
+		}
+
+		fmt.Fprintln(w, "TARGET\tADDRESS\tDRIVER\tNUMA\tLOCAL CPUS")
+		for _, arg := range args {
+			var devices []pcieinfo.PCIEDeviceInfo
+			if strings.HasPrefix(arg, "/") && !strings.HasPrefix(arg, "/dev/") {
+				devices, err = pcieInfo.FindDevicesByPath(arg)
+			} else {
+				devices, err = pcieInfo.FindDevicesByBlockDevice(arg)
+			}
+			if err != nil {
+				return err
+			}
+			for _, device := range devices {
+				localCpus, err := device.LocalCPUs()
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", arg, device.Address, device.Driver, device.NUMANode, localCpus)
+			}
+		}
+		return w.Flush()
+	},
+}
+
+// formatBytes formats a size in decimal units, as drive vendors do.
+func formatBytes(size uint64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB", "PB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+func init() {
+	rootCmd.AddCommand(blockCmd)
+}
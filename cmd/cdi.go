@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/cdi"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var (
+	cdiKind    string
+	cdiClasses []string
+)
+
+var cdiCmd = &cobra.Command{
+	Use:   "cdi",
+	Short: "Report a CDI spec for the PCIe devices of this machine",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+
+		classes := make([]cdi.Class, 0, len(cdiClasses))
+		for _, class := range cdiClasses {
+			classes = append(classes, cdi.Class(class))
+		}
+		devices, err := getPCIEDevices(pcieInfo)
+		if err != nil {
+			return err
+		}
+		spec, err := cdi.NewSpec(cdiKind, devices, classes)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(spec, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+func init() {
+	cdiCmd.Flags().StringVar(&cdiKind, "kind", "schedmd.com/pci", "CDI kind of the devices")
+	cdiCmd.Flags().StringSliceVar(&cdiClasses, "class", []string{string(cdi.VFIOClass)},
+		fmt.Sprintf("Device classes to include %v", cdi.Classes))
+	rootCmd.AddCommand(cdiCmd)
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var (
+	healthAll                  bool
+	healthCorrectableThreshold uint64
+)
+
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Report PCIe devices with AER errors or in D3",
+	Long: `Report PCIe devices with AER errors or in D3.
+
+The command fails when any device is unhealthy, so it can be used as a node
+health check.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		devices, err := getPCIEDevices(pcieInfo)
+		if err != nil {
+			return err
+		}
+
+		unhealthy := 0
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDRESS\tDRIVER\tPOWER\tLINK\tSTATUS")
+		for _, device := range devices {
+			problems := device.Health.Problems(healthCorrectableThreshold)
+			if len(problems) > 0 {
+				unhealthy++
+			} else if !healthAll {
+				continue
+			}
+
+			power := "-"
+			if device.Health != nil && device.Health.PowerState != "" {
+				power = device.Health.PowerState
+			}
+			link := "-"
+			if device.Link != nil {
+				link = fmt.Sprintf("%s x%d", device.Link.CurrentSpeed, device.Link.CurrentWidth)
+			}
+			status := "ok"
+			if len(problems) > 0 {
+				status = strings.Join(problems, ", ")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", device.Address, device.Driver, power, link, status)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if unhealthy > 0 {
+			return fmt.Errorf("%d unhealthy PCIe devices", unhealthy)
+		}
+		return nil
+	},
+}
+
+func init() {
+	healthCmd.Flags().BoolVar(&healthAll, "all", false, "Also report healthy devices")
+	healthCmd.Flags().Uint64Var(&healthCorrectableThreshold, "correctable-threshold", 0, "Correctable errors tolerated per device")
+	rootCmd.AddCommand(healthCmd)
+}
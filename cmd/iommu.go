@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var iommuCmd = &cobra.Command{
+	Use:   "iommu",
+	Short: "Report IOMMU groups and whether they can be passed through",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+
+		devices, err := getPCIEDevices(pcieInfo)
+		if err != nil {
+			return err
+		}
+		selected := make(map[string]bool, len(devices))
+		for _, device := range devices {
+			selected[device.Address] = true
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "GROUP\tVIABLE\tDEVICES")
+		for _, group := range pcieInfo.IOMMUGroups() {
+			if !slices.ContainsFunc(group.Devices, func(address string) bool { return selected[address] }) {
+				continue
+			}
+			members := make([]string, 0, len(group.Devices))
+			for _, address := range group.Devices {
+				driver := "unknown"
+				if device, ok := pcieInfo.FindDeviceByAddress(address); ok {
+					driver = device.Driver
+					if driver == "" {
+						driver = "unbound"
+					}
+				}
+				members = append(members, fmt.Sprintf("%s (%s)", address, driver))
+			}
+			viable := "yes"
+			if !group.Viable {
+				viable = "no"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", group.ID, viable, strings.Join(members, ", "))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		header := false
+		for _, device := range devices {
+			if device.ACS == nil {
+				continue
+			}
+			if !header {
+				fmt.Println("\nACS:")
+				header = true
+			}
+			fmt.Printf("  %s cap: %s ctl: %s\n", device.Address,
+				strings.Join(device.ACS.Capabilities, " "), strings.Join(device.ACS.Controls, " "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(iommuCmd)
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/irq"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var irqAvoidCPUs string
+
+var irqCmd = &cobra.Command{
+	Use:   "irq",
+	Short: "Report which CPUs service the interrupts of PCIe devices",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reports, _, err := getIRQReports()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDRESS\tNUMA\tLOCAL CPUS\tIRQS\tSERVICING CPUS\tSTATUS")
+		for _, report := range reports {
+			status := "local"
+			if !report.LocalityKnown() {
+				status = "unknown"
+			} else if !report.Local() {
+				status = "remote: " + report.RemoteCPUs.String()
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\t%s\n", report.Address, report.NUMANode,
+				report.LocalCPUs, len(report.Interrupts), report.ServicingCPUs, status)
+		}
+		return w.Flush()
+	},
+}
+
+var irqPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Propose interrupt affinities that avoid isolated and job CPUs",
+	Long: `Propose interrupt affinities that avoid isolated and job CPUs.
+
+The plan is printed as shell commands and is not applied. Isolated CPUs
+(isolcpus and nohz_full) are always avoided.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reports, cpuInfos, err := getIRQReports()
+		if err != nil {
+			return err
+		}
+
+		avoid := irq.ReadIsolatedCPUs()
+		if irqAvoidCPUs != "" {
+			cpus, err := cpuset.Parse(irqAvoidCPUs)
+			if err != nil {
+				return err
+			}
+			avoid = avoid.Union(cpus)
+		}
+		allCPUs := []int{}
+		for _, cpuInfo := range cpuInfos {
+			allCPUs = append(allCPUs, cpuInfo.CpuId)
+		}
+
+		assignments, err := irq.Plan(reports, cpuset.New(allCPUs...), avoid)
+		if err != nil {
+			return err
+		}
+		for _, assignment := range assignments {
+			if assignment.Remote {
+				fmt.Printf("%s # %s: no local CPU available\n", assignment, assignment.Address)
+			} else {
+				fmt.Printf("%s # %s\n", assignment, assignment.Address)
+			}
+		}
+		return nil
+	},
+}
+
+func getIRQReports() ([]irq.DeviceReport, []cpuinfo.CPUInfo, error) {
+	cpuInfos, err := getCPUInfos()
+	if err != nil {
+		return nil, nil, err
+	}
+	pcieInfo, err := pcieinfo.NewPCIEInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+	interrupts, err := irq.ReadInterrupts()
+	if err != nil {
+		return nil, nil, err
+	}
+	devices, err := getPCIEDevices(pcieInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	reports, err := irq.NewDeviceReports(devices, interrupts, cpuInfos)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reports, cpuInfos, nil
+}
+
+func init() {
+	irqPlanCmd.Flags().StringVar(&irqAvoidCPUs, "avoid", "", "Machine CPU list to keep free of interrupts (e.g. job CPUs 4-63)")
+	irqCmd.AddCommand(irqPlanCmd)
+	rootCmd.AddCommand(irqCmd)
+}
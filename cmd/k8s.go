@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pravk03/topologyutil/pkg/k8sexport"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var (
+	k8sNodeName string
+	k8sDriver   string
+)
+
+var k8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Report NodeResourceTopology and ResourceSlice objects as YAML",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cpuInfos, err := getCPUInfos()
+		if err != nil {
+			return err
+		}
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+
+		nodeName := k8sNodeName
+		if nodeName == "" {
+			nodeName, err = os.Hostname()
+			if err != nil {
+				return err
+			}
+		}
+
+		objects := []any{k8sexport.NewNodeResourceTopology(nodeName, cpuInfos)}
+		devices, err := getPCIEDevices(pcieInfo)
+		if err != nil {
+			return err
+		}
+		for _, resourceSlice := range k8sexport.NewResourceSlices(nodeName, k8sDriver, devices) {
+			objects = append(objects, resourceSlice)
+		}
+		for _, object := range objects {
+			data, err := yaml.Marshal(object)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("---\n%s", data)
+		}
+		return nil
+	},
+}
+
+func init() {
+	k8sCmd.Flags().StringVar(&k8sNodeName, "node-name", "", "Kubernetes node name (default hostname)")
+	k8sCmd.Flags().StringVar(&k8sDriver, "driver", "pcie.topologyutil.schedmd.com", "DRA driver name of the ResourceSlices")
+	rootCmd.AddCommand(k8sCmd)
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+	"github.com/pravk03/topologyutil/pkg/kubelet"
+)
+
+var kubeletCmd = &cobra.Command{
+	Use:   "kubelet",
+	Short: "Report how the kubelet CPU and memory managers assigned this machine",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cpuInfos, err := getCPUInfos()
+		if err != nil {
+			return err
+		}
+		cpuMap := cpumap.NewCPUMap(cpuInfos)
+
+		cpuState, err := kubelet.ReadCPUManagerState()
+		if err != nil {
+			return err
+		}
+		memState, err := kubelet.ReadMemoryManagerState()
+		if os.IsNotExist(err) {
+			memState = nil
+		} else if err != nil {
+			return err
+		}
+
+		report, err := kubelet.NewReport(cpuState, memState, cpuInfos, cpuMap)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(kubeletCmd)
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var linksDegradedOnly bool
+
+var linksCmd = &cobra.Command{
+	Use:   "links",
+	Short: "Report the PCIe link speed and width of devices",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		devices, err := getPCIEDevices(pcieInfo)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDRESS\tCURRENT\tMAX\tSTATUS")
+		for _, device := range devices {
+			link := device.Link
+			if link == nil || (linksDegradedOnly && !link.Degraded) {
+				continue
+			}
+			status := "ok"
+			if limitation := link.Limitation(); len(limitation) > 0 {
+				status += " (" + strings.Join(limitation, ", ") + ")"
+			}
+			if link.Degraded {
+				status = "degraded: " + strings.Join(link.Degradation(), ", ")
+			}
+			fmt.Fprintf(w, "%s\t%s x%d\t%s x%d\t%s\n", device.Address,
+				link.CurrentSpeed, link.CurrentWidth, link.MaxSpeed, link.MaxWidth, status)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	linksCmd.Flags().BoolVar(&linksDegradedOnly, "degraded", false, "Only report degraded links")
+	rootCmd.AddCommand(linksCmd)
+}
@@ -6,6 +6,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -15,7 +16,10 @@ import (
 	"github.com/pravk03/topologyutil/pkg/pcieinfo"
 )
 
-var noECore bool
+var (
+	noECore   bool
+	pciFilter string
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "cpuinfo",
@@ -33,11 +37,7 @@ var rootCmd = &cobra.Command{
 		println("")
 
 		// Show CPU Info
-		opts := []cpuinfo.CPUInfoOption{}
-		if noECore {
-			opts = append(opts, cpuinfo.WithoutECores())
-		}
-		cpuInfos, err := cpuinfo.GetCPUInfos(opts...)
+		cpuInfos, err := getCPUInfos()
 		if err != nil {
 			return err
 		}
@@ -64,7 +64,10 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		allDevices := pcieinfo.GetAllDevices()
+		allDevices, err := getPCIEDevices(pcieinfo)
+		if err != nil {
+			return err
+		}
 		data, err = json.MarshalIndent(allDevices, "", "  ")
 		if err != nil {
 			return err
@@ -76,8 +79,41 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// getCPUInfos returns the CPU infos, honoring the global flags, and warns
+// about inconsistent thread siblings.
+func getCPUInfos() ([]cpuinfo.CPUInfo, error) {
+	opts := []cpuinfo.CPUInfoOption{}
+	if noECore {
+		opts = append(opts, cpuinfo.WithoutECores())
+	}
+	cpuInfos, err := cpuinfo.GetCPUInfos(opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range cpumap.CheckThreadSiblings(cpuInfos) {
+		log.Printf("Warning: %s", problem)
+	}
+	return cpuInfos, nil
+}
+
+// getPCIFilter returns the filter of the --pci-filter expression.
+func getPCIFilter() (pcieinfo.Filter, error) {
+	return pcieinfo.ParseFilter(pciFilter)
+}
+
+// getPCIEDevices returns the devices matching the --pci-filter expression,
+// ordered by address.
+func getPCIEDevices(pcieInfo *pcieinfo.PCIEInfo) ([]pcieinfo.PCIEDeviceInfo, error) {
+	filter, err := getPCIFilter()
+	if err != nil {
+		return nil, err
+	}
+	return pcieInfo.Query(filter), nil
+}
+
 func init() {
-	rootCmd.Flags().BoolVar(&noECore, "no-ecores", false, "Avoid E-Cores")
+	rootCmd.PersistentFlags().BoolVar(&noECore, "no-ecores", false, "Avoid E-Cores")
+	rootCmd.PersistentFlags().StringVar(&pciFilter, "pci-filter", "", "Only report PCIe devices matching the expression (e.g. class=0302,numa=0)")
 }
 
 func main() {
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var nicsCmd = &cobra.Command{
+	Use:   "nics [INTERFACE...]",
+	Short: "Report the network interfaces of PCIe devices",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+
+		type entry struct {
+			device       pcieinfo.PCIEDeviceInfo
+			netInterface pcieinfo.NetInterface
+		}
+		entries := []entry{}
+		if len(args) > 0 {
+			for _, name := range args {
+				device, netInterface, ok := pcieInfo.FindDeviceByInterface(name)
+				if !ok {
+					return fmt.Errorf("network interface %s not found", name)
+				}
+				entries = append(entries, entry{device: device, netInterface: netInterface})
+			}
+		} else {
+			devices, err := getPCIEDevices(pcieInfo)
+			if err != nil {
+				return err
+			}
+			for _, device := range devices {
+				for _, netInterface := range device.NetInterfaces {
+					entries = append(entries, entry{device: device, netInterface: netInterface})
+				}
+			}
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "INTERFACE\tADDRESS\tDRIVER\tNUMA\tLOCAL CPUS\tMAC\tMTU\tSTATE\tSPEED\tQUEUES\tRDMA")
+		for _, e := range entries {
+			speed := "-"
+			if e.netInterface.Speed > 0 {
+				speed = fmt.Sprintf("%dMb/s", e.netInterface.Speed)
+			}
+			localCpus, err := e.device.LocalCPUs()
+			if err != nil {
+				return err
+			}
+			rdma := e.netInterface.RDMADevice
+			if rdma == "" {
+				rdma = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%d\t%s\t%s\t%d/%d\t%s\n",
+				e.netInterface.Name, e.device.Address, e.device.Driver, e.device.NUMANode,
+				localCpus, e.netInterface.MAC, e.netInterface.MTU,
+				e.netInterface.OperState, speed, e.netInterface.RxQueues, e.netInterface.TxQueues, rdma)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nicsCmd)
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+	"github.com/pravk03/topologyutil/pkg/slurm"
+)
+
+var (
+	slurmNodeName     string
+	slurmMemSpecLimit int
+	slurmReservedCPUs string
+	slurmGresType     string
+)
+
+var slurmCmd = &cobra.Command{
+	Use:   "slurm",
+	Short: "Report the slurm.conf node configuration of this machine",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cpuInfos, err := getCPUInfos()
+		if err != nil {
+			return err
+		}
+		cpuMap := cpumap.NewCPUMap(cpuInfos)
+
+		nodeName := slurmNodeName
+		if nodeName == "" {
+			nodeName, err = os.Hostname()
+			if err != nil {
+				return err
+			}
+		}
+		nodeConfig, err := slurm.NewNodeConfig(nodeName, cpuInfos, cpuMap)
+		if err != nil {
+			if !noECore {
+				err = fmt.Errorf("%w (try --no-ecores on hybrid CPUs)", err)
+			}
+			return err
+		}
+
+		nodeConfig.RealMemory, err = slurm.GetRealMemory()
+		if err != nil {
+			return err
+		}
+		nodeConfig.MemSpecLimit = slurmMemSpecLimit
+
+		if slurmReservedCPUs != "" {
+			reserved, err := cpuset.Parse(slurmReservedCPUs)
+			if err != nil {
+				return err
+			}
+			nodeConfig.CpuSpecList, err = slurm.ToCpuSpecList(cpuMap, reserved)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Println(nodeConfig.String())
+		return nil
+	},
+}
+
+var slurmGresCmd = &cobra.Command{
+	Use:   "gres",
+	Short: "Report the gres.conf GPU configuration of this machine",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cpuInfos, err := getCPUInfos()
+		if err != nil {
+			return err
+		}
+		cpuMap := cpumap.NewCPUMap(cpuInfos)
+
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		devices, err := getPCIEDevices(pcieInfo)
+		if err != nil {
+			return err
+		}
+		gresConfigs, err := slurm.NewGPUGresConfigs(devices, cpuMap, slurmGresType)
+		if err != nil {
+			return err
+		}
+		for _, gresConfig := range gresConfigs {
+			fmt.Println(gresConfig.String())
+		}
+		return nil
+	},
+}
+
+func init() {
+	slurmCmd.Flags().StringVar(&slurmNodeName, "node-name", "", "NodeName to report (default hostname)")
+	slurmCmd.Flags().IntVar(&slurmMemSpecLimit, "mem-spec-limit", 0, "Memory in MiB reserved for system use")
+	slurmCmd.Flags().StringVar(&slurmReservedCPUs, "reserved-cpus", "", "Machine CPU list reserved for system use (e.g. 0,1)")
+	rootCmd.AddCommand(slurmCmd)
+
+	slurmGresCmd.Flags().StringVar(&slurmGresType, "type", "", "GPU Type to report (e.g. a100)")
+	slurmCmd.AddCommand(slurmGresCmd)
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var sriovCmd = &cobra.Command{
+	Use:   "sriov",
+	Short: "Report SR-IOV physical functions and their virtual functions",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		filter, err := getPCIFilter()
+		if err != nil {
+			return err
+		}
+		for _, tree := range pcieInfo.SRIOVTrees() {
+			if !filter(tree.PF) {
+				continue
+			}
+			fmt.Print(tree.String())
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sriovCmd)
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var topoCmd = &cobra.Command{
+	Use:   "topo [ADDRESS...]",
+	Short: "Report the PCIe affinity matrix of devices",
+	Long: `Report the PCIe affinity matrix of devices, like nvidia-smi topo -m.
+
+Without arguments, all display, network and processing accelerator endpoints
+are reported, or those matching --pci-filter.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		topology := pcieInfo.NewTopology()
+
+		addresses := args
+		if len(addresses) == 0 {
+			filter := pcieinfo.Or(pcieinfo.ByClass("02"), pcieinfo.ByClass("03"), pcieinfo.ByClass("12"))
+			if pciFilter != "" {
+				if filter, err = getPCIFilter(); err != nil {
+					return err
+				}
+			}
+			for _, device := range pcieInfo.Query(filter) {
+				addresses = append(addresses, device.Address)
+			}
+		}
+
+		matrix, err := topology.AffinityMatrix(addresses)
+		if err != nil {
+			return err
+		}
+		fmt.Print(matrix.String())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(topoCmd)
+}
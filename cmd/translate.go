@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+	"github.com/pravk03/topologyutil/pkg/slurm"
+)
+
+var translateCmd = &cobra.Command{
+	Use:   "translate (abstract|machine) CPUS",
+	Short: "Translate between Slurm abstract CPUs and machine CPUs",
+	Long: `Translate between Slurm abstract CPUs and machine CPUs.
+
+CPUS is a range list (e.g. 0-3,8) or a hex mask (e.g. 0xf). A comma separated
+list of hex masks, as found in SLURM_CPU_BIND_LIST, is translated mask by mask.
+Each result is printed as a range list followed by its hex mask.`,
+	Example: `  cpuinfo translate abstract 17
+  cpuinfo translate machine 0,1,64,65
+  cpuinfo translate abstract "$SLURM_CPU_BIND_LIST"`,
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: []string{"abstract", "machine"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cpuInfos, err := getCPUInfos()
+		if err != nil {
+			return err
+		}
+		cpuMap := cpumap.NewCPUMap(cpuInfos)
+
+		for _, cpus := range slurm.SplitMaskList(args[1]) {
+			in, err := slurm.ParseCPUList(cpus)
+			if err != nil {
+				return fmt.Errorf("failed to parse %q: %w", cpus, err)
+			}
+
+			var out []int
+			switch args[0] {
+			case "abstract":
+				macCpuSet, err := slurm.AbstractToMachine(cpuMap, in)
+				if err != nil {
+					return err
+				}
+				out = macCpuSet.List()
+			case "machine":
+				absBitmap, err := slurm.MachineToAbstract(cpuMap, cpuset.New(bitmaputil.List(in)...))
+				if err != nil {
+					return err
+				}
+				out = bitmaputil.List(absBitmap)
+			default:
+				return fmt.Errorf("unknown CPU numbering %q, expected abstract or machine", args[0])
+			}
+			fmt.Printf("%s\t%s\n", cpuset.New(out...).String(), bitmaputil.String(bitmaputil.New(out...)))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(translateCmd)
+}
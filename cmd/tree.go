@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Report the PCIe hierarchy of this machine",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		filter, err := getPCIFilter()
+		if err != nil {
+			return err
+		}
+		topology := pcieInfo.NewTopology()
+		if pciFilter != "" {
+			topology.Prune(filter)
+		}
+		fmt.Print(topology.String())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+}
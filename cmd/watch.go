@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/watch"
+)
+
+var watchPollInterval time.Duration
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Report CPU and PCIe hotplug events as they happen",
+	Long: `Report CPU and PCIe hotplug events as they happen.
+
+Events are read from kernel uevents over netlink, falling back to scanning
+sysfs when the socket is unavailable. PCI events are only reported for the
+devices matching --pci-filter.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := getPCIFilter()
+		if err != nil {
+			return err
+		}
+
+		var source watch.Source
+		if watchPollInterval > 0 {
+			source = watch.NewPollSource(watchPollInterval)
+		} else if netlinkSource, err := watch.NewNetlinkSource(); err != nil {
+			log.Printf("Warning: %v, polling sysfs instead", err)
+			source = watch.NewPollSource(watch.DefaultPollInterval)
+		} else {
+			defer netlinkSource.Close()
+			source = netlinkSource
+		}
+
+		opts := []watch.Option{}
+		if noECore {
+			opts = append(opts, watch.WithCPUInfoOptions(cpuinfo.WithoutECores()))
+		}
+		watcher, err := watch.NewWatcher(source, opts...)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return watcher.Run(ctx, func(event watch.Event) {
+			if event.Device != nil && !filter(*event.Device) {
+				return
+			}
+			switch event.Type {
+			case watch.CPUOnline, watch.CPUOffline, watch.Resync:
+				fmt.Printf("%s %s cpus=%d\n", time.Now().Format(time.RFC3339), event, len(watcher.CPUInfos()))
+			default:
+				fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), event)
+			}
+		})
+	},
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchPollInterval, "poll", 0, "Scan sysfs at this interval instead of reading uevents")
+	rootCmd.AddCommand(watchCmd)
+}
@@ -9,6 +9,7 @@ require (
 	github.com/spf13/cobra v1.9.1
 	gvisor.dev/gvisor v0.0.0-20250610232857-cab42c621689
 	k8s.io/utils v0.0.0-20241210054802-24370beab758
+	sigs.k8s.io/yaml v1.4.0
 )
 
 require (
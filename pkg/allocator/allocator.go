@@ -0,0 +1,373 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package allocator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/kelindar/bitmap"
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+)
+
+// Policy selects how cores are placed across the machine topology.
+type Policy int
+
+const (
+	// PackPolicy places cores into the fewest sockets, then into the fewest
+	// NUMA nodes within each socket, then into the fewest L3 caches within
+	// each NUMA node.
+	PackPolicy Policy = iota
+
+	// SpreadPolicy distributes cores evenly across sockets.
+	SpreadPolicy
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PackPolicy:
+		return "pack"
+	case SpreadPolicy:
+		return "spread"
+	default:
+		return "Policy(" + strconv.Itoa(int(p)) + ")"
+	}
+}
+
+// Allocation holds the CPUs chosen by the Allocator.
+type Allocation struct {
+	// Abstract is the set of abstract CPUs (cores) touched by the allocation.
+	Abstract bitmap.Bitmap
+
+	// Machine is the set of machine CPUs allocated.
+	Machine cpuset.CPUSet
+}
+
+// core is an abstract CPU annotated with its place in the topology.
+type core struct {
+	absId    int
+	cpus     []int
+	socketId int
+	numaNode int
+	l3       string
+}
+
+// Allocator allocates CPUs from a CPUMap in whole abstract CPUs.
+type Allocator struct {
+	cores []core
+}
+
+// NewAllocator returns an Allocator over the abstract CPUs of the CPUMap,
+// using the CPU infos for socket, NUMA node and L3 cache placement.
+func NewAllocator(cpuMap cpumap.CPUMap, cpuInfos []cpuinfo.CPUInfo) *Allocator {
+	byCpuId := make(map[int]cpuinfo.CPUInfo, len(cpuInfos))
+	for _, cpuInfo := range cpuInfos {
+		byCpuId[cpuInfo.CpuId] = cpuInfo
+	}
+
+	cores := make([]core, len(cpuMap.AbstractToMachine))
+	for absId, cpuSet := range cpuMap.AbstractToMachine {
+		c := core{
+			absId:    absId,
+			cpus:     cpuSet.List(),
+			socketId: -1,
+			numaNode: -1,
+		}
+		if len(c.cpus) > 0 {
+			if cpuInfo, ok := byCpuId[c.cpus[0]]; ok {
+				c.socketId = cpuInfo.SocketId
+				c.numaNode = cpuInfo.NumaNode
+				c.l3 = l3Key(cpuInfo)
+			}
+		}
+		cores[absId] = c
+	}
+
+	return &Allocator{
+		cores: cores,
+	}
+}
+
+// l3Key identifies the L3 cache of the CPU, falling back to its NUMA node
+// when the kernel does not expose cache topology.
+func l3Key(cpuInfo cpuinfo.CPUInfo) string {
+	if cpuInfo.L3Siblings != "" {
+		if siblings, err := cpuset.Parse(cpuInfo.L3Siblings); err == nil {
+			return "l3:" + siblings.String()
+		}
+	}
+	return "node:" + strconv.Itoa(cpuInfo.NumaNode)
+}
+
+type allocateOptions struct {
+	policy     Policy
+	splitCores bool
+}
+
+type AllocateOption func(opts *allocateOptions)
+
+// WithPolicy selects the placement policy. The default is PackPolicy.
+func WithPolicy(policy Policy) AllocateOption {
+	return func(opts *allocateOptions) {
+		opts.policy = policy
+	}
+}
+
+// WithSplitCores allows the allocation to take only some SMT siblings of a
+// core. By default only whole cores are allocated.
+func WithSplitCores() AllocateOption {
+	return func(opts *allocateOptions) {
+		opts.splitCores = true
+	}
+}
+
+// Allocate chooses numCPUs machine CPUs from the abstract CPUs not in used.
+// Every abstract CPU touched by the allocation is reported in the result,
+// including a core that was only partially allocated by WithSplitCores.
+// Without WithSplitCores, numCPUs must add up to whole cores; use
+// AllocateCores to count in cores instead.
+func (a *Allocator) Allocate(used bitmap.Bitmap, numCPUs int, options ...AllocateOption) (Allocation, error) {
+	opts := &allocateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if numCPUs <= 0 {
+		return Allocation{}, fmt.Errorf("invalid number of CPUs: %d", numCPUs)
+	}
+
+	free := a.freeCores(used)
+	if available := countCPUs(free); available < numCPUs {
+		return Allocation{}, fmt.Errorf("not enough free CPUs: requested %d, available %d", numCPUs, available)
+	}
+
+	ordered, err := order(free, numCPUs, cpuCount, opts.policy)
+	if err != nil {
+		return Allocation{}, err
+	}
+	return take(ordered, numCPUs, opts.splitCores)
+}
+
+// AllocateCores chooses numCores whole abstract CPUs (cores) from those not
+// in used, with all their machine CPUs. WithSplitCores has no effect.
+func (a *Allocator) AllocateCores(used bitmap.Bitmap, numCores int, options ...AllocateOption) (Allocation, error) {
+	opts := &allocateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if numCores <= 0 {
+		return Allocation{}, fmt.Errorf("invalid number of cores: %d", numCores)
+	}
+
+	free := a.freeCores(used)
+	if len(free) < numCores {
+		return Allocation{}, fmt.Errorf("not enough free cores: requested %d, available %d", numCores, len(free))
+	}
+
+	ordered, err := order(free, numCores, coreCount, opts.policy)
+	if err != nil {
+		return Allocation{}, err
+	}
+	return takeCores(ordered, numCores), nil
+}
+
+// freeCores returns the cores not in used.
+func (a *Allocator) freeCores(used bitmap.Bitmap) []core {
+	free := []core{}
+	for _, c := range a.cores {
+		if !used.Contains(uint32(c.absId)) {
+			free = append(free, c)
+		}
+	}
+	return free
+}
+
+// countFunc is the size of a core in the unit of the allocation.
+type countFunc func(c core) int
+
+func cpuCount(c core) int {
+	return len(c.cpus)
+}
+
+func coreCount(c core) int {
+	return 1
+}
+
+// order sorts the cores by preference for allocating size units, as counted
+// by count, under the policy.
+func order(cores []core, size int, count countFunc, policy Policy) ([]core, error) {
+	switch policy {
+	case PackPolicy:
+		return packOrder(cores, size, count, bySocket, byNumaNode, byL3), nil
+	case SpreadPolicy:
+		return spreadOrder(cores, size, count), nil
+	default:
+		return nil, fmt.Errorf("unknown allocation policy: %v", policy)
+	}
+}
+
+// take allocates numCPUs from the cores in order of preference.
+func take(ordered []core, numCPUs int, splitCores bool) (Allocation, error) {
+	absCpus := []int{}
+	macCpus := []int{}
+	remaining := numCPUs
+	for _, c := range ordered {
+		if remaining == 0 {
+			break
+		}
+		switch {
+		case len(c.cpus) <= remaining:
+			macCpus = append(macCpus, c.cpus...)
+			remaining -= len(c.cpus)
+		case splitCores:
+			macCpus = append(macCpus, c.cpus[:remaining]...)
+			remaining = 0
+		default:
+			// Look for a smaller core rather than splitting this one.
+			continue
+		}
+		absCpus = append(absCpus, c.absId)
+	}
+	if remaining > 0 {
+		return Allocation{}, fmt.Errorf("cannot allocate %d CPUs without splitting cores", numCPUs)
+	}
+
+	return Allocation{
+		Abstract: bitmaputil.New(absCpus...),
+		Machine:  cpuset.New(macCpus...),
+	}, nil
+}
+
+// takeCores allocates the first numCores cores in order of preference.
+func takeCores(ordered []core, numCores int) Allocation {
+	absCpus := []int{}
+	macCpus := []int{}
+	for _, c := range ordered[:numCores] {
+		absCpus = append(absCpus, c.absId)
+		macCpus = append(macCpus, c.cpus...)
+	}
+	return Allocation{
+		Abstract: bitmaputil.New(absCpus...),
+		Machine:  cpuset.New(macCpus...),
+	}
+}
+
+type groupKeyFunc func(c core) string
+
+func byNumaNode(c core) string {
+	return strconv.Itoa(c.numaNode)
+}
+
+func byL3(c core) string {
+	return c.l3
+}
+
+func bySocket(c core) string {
+	return strconv.Itoa(c.socketId)
+}
+
+type group struct {
+	cores []core
+	size  int
+}
+
+// groupBy partitions the cores by key, in order of first appearance, sizing
+// each group with count.
+func groupBy(cores []core, key groupKeyFunc, count countFunc) []group {
+	groups := []group{}
+	index := map[string]int{}
+	for _, c := range cores {
+		k := key(c)
+		idx, ok := index[k]
+		if !ok {
+			idx = len(groups)
+			index[k] = idx
+			groups = append(groups, group{})
+		}
+		groups[idx].cores = append(groups[idx].cores, c)
+		groups[idx].size += count(c)
+	}
+	return groups
+}
+
+// packOrder orders the cores so that the first size units come from the
+// fewest groups at each level. At every step the smallest group that can
+// satisfy the remaining units is chosen, otherwise the largest group is
+// consumed entirely.
+func packOrder(cores []core, size int, count countFunc, keys ...groupKeyFunc) []core {
+	if len(keys) == 0 {
+		return cores
+	}
+
+	groups := groupBy(cores, keys[0], count)
+	ordered := make([]core, 0, len(cores))
+	remaining := size
+	for len(groups) > 0 {
+		idx := pickGroup(groups, remaining)
+		g := groups[idx]
+		ordered = append(ordered, packOrder(g.cores, max(remaining, 0), count, keys[1:]...)...)
+		remaining -= g.size
+		groups = slices.Delete(groups, idx, idx+1)
+	}
+	return ordered
+}
+
+// pickGroup returns the index of the best fitting group for size units.
+func pickGroup(groups []group, size int) int {
+	bestFit := -1
+	largest := 0
+	for i, g := range groups {
+		if g.size >= size && (bestFit < 0 || g.size < groups[bestFit].size) {
+			bestFit = i
+		}
+		if g.size > groups[largest].size {
+			largest = i
+		}
+	}
+	if bestFit >= 0 {
+		return bestFit
+	}
+	return largest
+}
+
+// spreadOrder orders the cores round-robin across sockets, packing within
+// each socket.
+func spreadOrder(cores []core, size int, count countFunc) []core {
+	sockets := groupBy(cores, bySocket, count)
+	slices.SortStableFunc(sockets, func(a, b group) int {
+		return a.cores[0].socketId - b.cores[0].socketId
+	})
+
+	perSocket := (size + len(sockets) - 1) / len(sockets)
+	queues := make([][]core, len(sockets))
+	for i, socket := range sockets {
+		queues[i] = packOrder(socket.cores, perSocket, count, byNumaNode, byL3)
+	}
+
+	ordered := make([]core, 0, len(cores))
+	for len(ordered) < len(cores) {
+		for i := range queues {
+			if len(queues[i]) == 0 {
+				continue
+			}
+			ordered = append(ordered, queues[i][0])
+			queues[i] = queues[i][1:]
+		}
+	}
+	return ordered
+}
+
+func countCPUs(cores []core) int {
+	numCPUs := 0
+	for _, c := range cores {
+		numCPUs += len(c.cpus)
+	}
+	return numCPUs
+}
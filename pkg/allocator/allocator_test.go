@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package allocator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kelindar/bitmap"
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+)
+
+// testCpuInfos returns a 2 socket machine with 2 NUMA nodes per socket,
+// 4 cores per NUMA node and 2 threads per core. Abstract CPU N is core N,
+// whose machine CPUs are N and N+16.
+func testCpuInfos() []cpuinfo.CPUInfo {
+	cpuInfos := []cpuinfo.CPUInfo{}
+	for thread := range 2 {
+		for coreId := range 16 {
+			cpuInfos = append(cpuInfos, cpuinfo.CPUInfo{
+				CpuId:    thread*16 + coreId,
+				SocketId: coreId / 8,
+				CoreId:   coreId % 8,
+				NumaNode: coreId / 4,
+			})
+		}
+	}
+	return cpuInfos
+}
+
+func TestAllocator_Allocate(t *testing.T) {
+	type args struct {
+		used    bitmap.Bitmap
+		numCPUs int
+		options []AllocateOption
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantAbstract bitmap.Bitmap
+		wantMachine  cpuset.CPUSet
+		wantErr      bool
+	}{
+		{
+			name: "pack single node",
+			args: args{
+				used:    bitmaputil.New(),
+				numCPUs: 4,
+			},
+			wantAbstract: bitmaputil.New(0, 1),
+			wantMachine:  cpuset.New(0, 1, 16, 17),
+		},
+		{
+			name: "pack best fit node",
+			args: args{
+				used:    bitmaputil.New(0, 4, 5),
+				numCPUs: 4,
+			},
+			wantAbstract: bitmaputil.New(6, 7),
+			wantMachine:  cpuset.New(6, 7, 22, 23),
+		},
+		{
+			name: "pack fewest nodes",
+			args: args{
+				used:    bitmaputil.New(0, 1),
+				numCPUs: 8,
+			},
+			wantAbstract: bitmaputil.New(4, 5, 6, 7),
+			wantMachine:  cpuset.New(4, 5, 6, 7, 20, 21, 22, 23),
+		},
+		{
+			// Fewer nodes would take a full node from the other socket.
+			name: "pack one socket before fewest nodes",
+			args: args{
+				used:    bitmaputil.New(0, 1, 4, 13),
+				numCPUs: 10,
+			},
+			wantAbstract: bitmaputil.New(2, 3, 5, 6, 7),
+			wantMachine:  cpuset.New(2, 3, 5, 6, 7, 18, 19, 21, 22, 23),
+		},
+		{
+			name: "pack overflowing a socket",
+			args: args{
+				used:    bitmaputil.New(),
+				numCPUs: 18,
+			},
+			wantAbstract: bitmaputil.New(0, 1, 2, 3, 4, 5, 6, 7, 8),
+			wantMachine:  cpuset.New(0, 1, 2, 3, 4, 5, 6, 7, 8, 16, 17, 18, 19, 20, 21, 22, 23, 24),
+		},
+		{
+			name: "spread across sockets",
+			args: args{
+				used:    bitmaputil.New(),
+				numCPUs: 8,
+				options: []AllocateOption{WithPolicy(SpreadPolicy)},
+			},
+			wantAbstract: bitmaputil.New(0, 1, 8, 9),
+			wantMachine:  cpuset.New(0, 1, 8, 9, 16, 17, 24, 25),
+		},
+		{
+			name: "whole cores only",
+			args: args{
+				used:    bitmaputil.New(),
+				numCPUs: 3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "split cores",
+			args: args{
+				used:    bitmaputil.New(),
+				numCPUs: 3,
+				options: []AllocateOption{WithSplitCores()},
+			},
+			wantAbstract: bitmaputil.New(0, 1),
+			wantMachine:  cpuset.New(0, 1, 16),
+		},
+		{
+			name: "not enough CPUs",
+			args: args{
+				used:    bitmaputil.New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14),
+				numCPUs: 4,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid number of CPUs",
+			args: args{
+				used:    bitmaputil.New(),
+				numCPUs: 0,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpuInfos := testCpuInfos()
+			a := NewAllocator(cpumap.NewCPUMap(cpuInfos), cpuInfos)
+			got, err := a.Allocate(tt.args.used, tt.args.numCPUs, tt.args.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Allocator.Allocate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Abstract, tt.wantAbstract) {
+				t.Errorf("Allocator.Allocate() Abstract = %v, want %v", bitmaputil.String(got.Abstract), bitmaputil.String(tt.wantAbstract))
+			}
+			if !got.Machine.Equals(tt.wantMachine) {
+				t.Errorf("Allocator.Allocate() Machine = %v, want %v", got.Machine, tt.wantMachine)
+			}
+		})
+	}
+}
+
+func TestAllocator_AllocateCores(t *testing.T) {
+	tests := []struct {
+		name         string
+		used         bitmap.Bitmap
+		numCores     int
+		options      []AllocateOption
+		wantAbstract bitmap.Bitmap
+		wantMachine  cpuset.CPUSet
+		wantErr      bool
+	}{
+		{
+			name:         "odd number of cores",
+			used:         bitmaputil.New(),
+			numCores:     3,
+			wantAbstract: bitmaputil.New(0, 1, 2),
+			wantMachine:  cpuset.New(0, 1, 2, 16, 17, 18),
+		},
+		{
+			name:         "pack best fit node",
+			used:         bitmaputil.New(0, 4),
+			numCores:     3,
+			wantAbstract: bitmaputil.New(1, 2, 3),
+			wantMachine:  cpuset.New(1, 2, 3, 17, 18, 19),
+		},
+		{
+			name:         "pack one socket before fewest nodes",
+			used:         bitmaputil.New(0, 1, 4, 13),
+			numCores:     5,
+			wantAbstract: bitmaputil.New(2, 3, 5, 6, 7),
+			wantMachine:  cpuset.New(2, 3, 5, 6, 7, 18, 19, 21, 22, 23),
+		},
+		{
+			name:         "cross socket",
+			used:         bitmaputil.New(),
+			numCores:     9,
+			wantAbstract: bitmaputil.New(0, 1, 2, 3, 4, 5, 6, 7, 8),
+			wantMachine:  cpuset.New(0, 1, 2, 3, 4, 5, 6, 7, 8, 16, 17, 18, 19, 20, 21, 22, 23, 24),
+		},
+		{
+			name:         "spread odd number of cores",
+			used:         bitmaputil.New(),
+			numCores:     3,
+			options:      []AllocateOption{WithPolicy(SpreadPolicy)},
+			wantAbstract: bitmaputil.New(0, 1, 8),
+			wantMachine:  cpuset.New(0, 1, 8, 16, 17, 24),
+		},
+		{
+			name:     "not enough cores",
+			used:     bitmaputil.New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13),
+			numCores: 3,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid number of cores",
+			used:     bitmaputil.New(),
+			numCores: 0,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpuInfos := testCpuInfos()
+			a := NewAllocator(cpumap.NewCPUMap(cpuInfos), cpuInfos)
+			got, err := a.AllocateCores(tt.used, tt.numCores, tt.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Allocator.AllocateCores() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Abstract, tt.wantAbstract) {
+				t.Errorf("Allocator.AllocateCores() Abstract = %v, want %v", bitmaputil.String(got.Abstract), bitmaputil.String(tt.wantAbstract))
+			}
+			if !got.Machine.Equals(tt.wantMachine) {
+				t.Errorf("Allocator.AllocateCores() Machine = %v, want %v", got.Machine, tt.wantMachine)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package allocator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/kelindar/bitmap"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+// Locality describes how close allocated CPUs are to a set of devices.
+type Locality int
+
+const (
+	// DeviceLocal means every CPU is in the devices' `local_cpus`.
+	DeviceLocal Locality = iota
+
+	// SocketLocal means every CPU shares a socket with the devices.
+	SocketLocal
+
+	// Remote means some CPUs are on a socket without any of the devices.
+	Remote
+)
+
+func (l Locality) String() string {
+	switch l {
+	case DeviceLocal:
+		return "device"
+	case SocketLocal:
+		return "socket"
+	case Remote:
+		return "remote"
+	default:
+		return "Locality(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+func (l Locality) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// AllocateNearDevices allocates numCPUs like Allocate, preferring the CPUs
+// local to the devices, then CPUs on the same sockets, then any CPUs. It also
+// reports the worst locality among the allocated cores.
+func (a *Allocator) AllocateNearDevices(devices []pcieinfo.PCIEDeviceInfo, used bitmap.Bitmap, numCPUs int, options ...AllocateOption) (Allocation, Locality, error) {
+	opts := &allocateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if numCPUs <= 0 {
+		return Allocation{}, Remote, fmt.Errorf("invalid number of CPUs: %d", numCPUs)
+	}
+
+	ordered, locality, err := a.orderNearDevices(devices, used, numCPUs, cpuCount, opts.policy)
+	if err != nil {
+		return Allocation{}, Remote, err
+	}
+	if available := countCPUs(ordered); available < numCPUs {
+		return Allocation{}, Remote, fmt.Errorf("not enough free CPUs: requested %d, available %d", numCPUs, available)
+	}
+
+	allocation, err := take(ordered, numCPUs, opts.splitCores)
+	if err != nil {
+		return Allocation{}, Remote, err
+	}
+	return allocation, worstLocality(allocation, locality), nil
+}
+
+// AllocateCoresNearDevices allocates numCores like AllocateCores, with the
+// preferences and locality of AllocateNearDevices.
+func (a *Allocator) AllocateCoresNearDevices(devices []pcieinfo.PCIEDeviceInfo, used bitmap.Bitmap, numCores int, options ...AllocateOption) (Allocation, Locality, error) {
+	opts := &allocateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if numCores <= 0 {
+		return Allocation{}, Remote, fmt.Errorf("invalid number of cores: %d", numCores)
+	}
+
+	ordered, locality, err := a.orderNearDevices(devices, used, numCores, coreCount, opts.policy)
+	if err != nil {
+		return Allocation{}, Remote, err
+	}
+	if len(ordered) < numCores {
+		return Allocation{}, Remote, fmt.Errorf("not enough free cores: requested %d, available %d", numCores, len(ordered))
+	}
+
+	allocation := takeCores(ordered, numCores)
+	return allocation, worstLocality(allocation, locality), nil
+}
+
+// AllocateNearAddresses is AllocateNearDevices for the devices with the PCI
+// addresses (e.g. "0000:3b:00.0").
+func (a *Allocator) AllocateNearAddresses(pcieInfo *pcieinfo.PCIEInfo, addresses []string, used bitmap.Bitmap, numCPUs int, options ...AllocateOption) (Allocation, Locality, error) {
+	devices, err := findDevices(pcieInfo, addresses)
+	if err != nil {
+		return Allocation{}, Remote, err
+	}
+	return a.AllocateNearDevices(devices, used, numCPUs, options...)
+}
+
+// AllocateCoresNearAddresses is AllocateCoresNearDevices for the devices with
+// the PCI addresses.
+func (a *Allocator) AllocateCoresNearAddresses(pcieInfo *pcieinfo.PCIEInfo, addresses []string, used bitmap.Bitmap, numCores int, options ...AllocateOption) (Allocation, Locality, error) {
+	devices, err := findDevices(pcieInfo, addresses)
+	if err != nil {
+		return Allocation{}, Remote, err
+	}
+	return a.AllocateCoresNearDevices(devices, used, numCores, options...)
+}
+
+func findDevices(pcieInfo *pcieinfo.PCIEInfo, addresses []string) ([]pcieinfo.PCIEDeviceInfo, error) {
+	devices := make([]pcieinfo.PCIEDeviceInfo, 0, len(addresses))
+	for _, address := range addresses {
+		device, ok := pcieInfo.FindDeviceByAddress(address)
+		if !ok {
+			return nil, fmt.Errorf("device %s not found", address)
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// orderNearDevices orders the cores not in used by locality to the devices,
+// then by policy within each locality, for allocating size units as counted
+// by count. It also returns the locality of every ordered core. A core is
+// local to the devices when any of its CPUs is.
+func (a *Allocator) orderNearDevices(devices []pcieinfo.PCIEDeviceInfo, used bitmap.Bitmap, size int, count countFunc, policy Policy) ([]core, map[int]Locality, error) {
+	localCpus, err := a.deviceLocalCPUs(devices)
+	if err != nil {
+		return nil, nil, err
+	}
+	isLocal := func(c core) bool {
+		return slices.ContainsFunc(c.cpus, func(cpuId int) bool { return localCpus[cpuId] })
+	}
+	localSockets := make(map[int]bool)
+	for _, c := range a.cores {
+		if isLocal(c) {
+			localSockets[c.socketId] = true
+		}
+	}
+
+	tiers := make([][]core, Remote+1)
+	locality := make(map[int]Locality, len(a.cores))
+	for _, c := range a.cores {
+		if used.Contains(uint32(c.absId)) {
+			continue
+		}
+		tier := Remote
+		switch {
+		case isLocal(c):
+			tier = DeviceLocal
+		case localSockets[c.socketId]:
+			tier = SocketLocal
+		}
+		tiers[tier] = append(tiers[tier], c)
+		locality[c.absId] = tier
+	}
+
+	ordered := []core{}
+	remaining := size
+	for _, cores := range tiers {
+		if len(cores) == 0 {
+			continue
+		}
+		tierOrder, err := order(cores, max(remaining, 1), count, policy)
+		if err != nil {
+			return nil, nil, err
+		}
+		ordered = append(ordered, tierOrder...)
+		for _, c := range cores {
+			remaining -= count(c)
+		}
+	}
+	return ordered, locality, nil
+}
+
+// worstLocality returns the worst locality among the allocated cores.
+func worstLocality(allocation Allocation, locality map[int]Locality) Locality {
+	achieved := DeviceLocal
+	allocation.Abstract.Range(func(idx uint32) {
+		achieved = max(achieved, locality[int(idx)])
+	})
+	return achieved
+}
+
+// deviceLocalCPUs returns the machine CPUs local to any of the devices. When
+// a device does not report `local_cpus`, the CPUs of its NUMA node are used,
+// and none when that is unknown too.
+func (a *Allocator) deviceLocalCPUs(devices []pcieinfo.PCIEDeviceInfo) (map[int]bool, error) {
+	localCpus := make(map[int]bool)
+	for _, device := range devices {
+		deviceCpus, err := device.LocalCPUs()
+		if err != nil {
+			return nil, err
+		}
+		if deviceCpus.Size() > 0 {
+			for _, cpuId := range deviceCpus.List() {
+				localCpus[cpuId] = true
+			}
+			continue
+		}
+		if device.NUMANode == pcieinfo.UnknownNUMANode {
+			continue
+		}
+		for _, c := range a.cores {
+			if c.numaNode == device.NUMANode {
+				for _, cpuId := range c.cpus {
+					localCpus[cpuId] = true
+				}
+			}
+		}
+	}
+	return localCpus, nil
+}
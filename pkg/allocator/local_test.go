@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package allocator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kelindar/bitmap"
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+func TestAllocator_AllocateNearDevices(t *testing.T) {
+	gpu := pcieinfo.PCIEDeviceInfo{
+		Address:              "0000:3b:00.0",
+		NUMANode:             0,
+		NumaNodeAffinityMask: "0xf000f",
+	}
+	type args struct {
+		devices []pcieinfo.PCIEDeviceInfo
+		used    bitmap.Bitmap
+		numCPUs int
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantAbstract bitmap.Bitmap
+		wantMachine  cpuset.CPUSet
+		wantLocality Locality
+		wantErr      bool
+	}{
+		{
+			name: "device local",
+			args: args{
+				devices: []pcieinfo.PCIEDeviceInfo{gpu},
+				used:    bitmaputil.New(),
+				numCPUs: 4,
+			},
+			wantAbstract: bitmaputil.New(0, 1),
+			wantMachine:  cpuset.New(0, 1, 16, 17),
+			wantLocality: DeviceLocal,
+		},
+		{
+			name: "socket local",
+			args: args{
+				devices: []pcieinfo.PCIEDeviceInfo{gpu},
+				used:    bitmaputil.New(0, 1, 2),
+				numCPUs: 4,
+			},
+			wantAbstract: bitmaputil.New(3, 4),
+			wantMachine:  cpuset.New(3, 4, 19, 20),
+			wantLocality: SocketLocal,
+		},
+		{
+			name: "remote",
+			args: args{
+				devices: []pcieinfo.PCIEDeviceInfo{gpu},
+				used:    bitmaputil.New(0, 1, 2, 3, 4, 5, 6, 7),
+				numCPUs: 2,
+			},
+			wantAbstract: bitmaputil.New(8),
+			wantMachine:  cpuset.New(8, 24),
+			wantLocality: Remote,
+		},
+		{
+			name: "no local cpus falls back to NUMA node",
+			args: args{
+				devices: []pcieinfo.PCIEDeviceInfo{
+					{Address: "0000:c1:00.0", NUMANode: 3, NumaNodeAffinityMask: "0x"},
+				},
+				used:    bitmaputil.New(),
+				numCPUs: 2,
+			},
+			wantAbstract: bitmaputil.New(12),
+			wantMachine:  cpuset.New(12, 28),
+			wantLocality: DeviceLocal,
+		},
+		{
+			name: "unknown NUMA node is not local",
+			args: args{
+				devices: []pcieinfo.PCIEDeviceInfo{
+					{Address: "0000:c1:00.0", NUMANode: pcieinfo.UnknownNUMANode, NumaNodeAffinityMask: "0x"},
+				},
+				used:    bitmaputil.New(),
+				numCPUs: 2,
+			},
+			wantAbstract: bitmaputil.New(0),
+			wantMachine:  cpuset.New(0, 16),
+			wantLocality: Remote,
+		},
+		{
+			name: "invalid mask",
+			args: args{
+				devices: []pcieinfo.PCIEDeviceInfo{
+					{Address: "0000:c1:00.0", NumaNodeAffinityMask: "0xzz"},
+				},
+				used:    bitmaputil.New(),
+				numCPUs: 2,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpuInfos := testCpuInfos()
+			a := NewAllocator(cpumap.NewCPUMap(cpuInfos), cpuInfos)
+			got, gotLocality, err := a.AllocateNearDevices(tt.args.devices, tt.args.used, tt.args.numCPUs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Allocator.AllocateNearDevices() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Abstract, tt.wantAbstract) {
+				t.Errorf("Allocator.AllocateNearDevices() Abstract = %v, want %v", bitmaputil.String(got.Abstract), bitmaputil.String(tt.wantAbstract))
+			}
+			if !got.Machine.Equals(tt.wantMachine) {
+				t.Errorf("Allocator.AllocateNearDevices() Machine = %v, want %v", got.Machine, tt.wantMachine)
+			}
+			if gotLocality != tt.wantLocality {
+				t.Errorf("Allocator.AllocateNearDevices() Locality = %v, want %v", gotLocality, tt.wantLocality)
+			}
+		})
+	}
+}
+
+func TestAllocator_AllocateCoresNearDevices(t *testing.T) {
+	gpu := pcieinfo.PCIEDeviceInfo{
+		Address:              "0000:3b:00.0",
+		NUMANode:             0,
+		NumaNodeAffinityMask: "0xf000f",
+	}
+	tests := []struct {
+		name         string
+		devices      []pcieinfo.PCIEDeviceInfo
+		used         bitmap.Bitmap
+		numCores     int
+		wantAbstract bitmap.Bitmap
+		wantMachine  cpuset.CPUSet
+		wantLocality Locality
+		wantErr      bool
+	}{
+		{
+			name:         "device local",
+			devices:      []pcieinfo.PCIEDeviceInfo{gpu},
+			used:         bitmaputil.New(0),
+			numCores:     3,
+			wantAbstract: bitmaputil.New(1, 2, 3),
+			wantMachine:  cpuset.New(1, 2, 3, 17, 18, 19),
+			wantLocality: DeviceLocal,
+		},
+		{
+			name:         "socket local",
+			devices:      []pcieinfo.PCIEDeviceInfo{gpu},
+			used:         bitmaputil.New(0, 1, 2),
+			numCores:     2,
+			wantAbstract: bitmaputil.New(3, 4),
+			wantMachine:  cpuset.New(3, 4, 19, 20),
+			wantLocality: SocketLocal,
+		},
+		{
+			// Only the second thread of core 0 is in local_cpus.
+			name: "any local thread makes the core local",
+			devices: []pcieinfo.PCIEDeviceInfo{
+				{Address: "0000:c1:00.0", NUMANode: 0, NumaNodeAffinityMask: "0x10000"},
+			},
+			used:         bitmaputil.New(),
+			numCores:     1,
+			wantAbstract: bitmaputil.New(0),
+			wantMachine:  cpuset.New(0, 16),
+			wantLocality: DeviceLocal,
+		},
+		{
+			name:     "not enough cores",
+			devices:  []pcieinfo.PCIEDeviceInfo{gpu},
+			used:     bitmaputil.New(),
+			numCores: 17,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpuInfos := testCpuInfos()
+			a := NewAllocator(cpumap.NewCPUMap(cpuInfos), cpuInfos)
+			got, gotLocality, err := a.AllocateCoresNearDevices(tt.devices, tt.used, tt.numCores)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Allocator.AllocateCoresNearDevices() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Abstract, tt.wantAbstract) {
+				t.Errorf("Allocator.AllocateCoresNearDevices() Abstract = %v, want %v", bitmaputil.String(got.Abstract), bitmaputil.String(tt.wantAbstract))
+			}
+			if !got.Machine.Equals(tt.wantMachine) {
+				t.Errorf("Allocator.AllocateCoresNearDevices() Machine = %v, want %v", got.Machine, tt.wantMachine)
+			}
+			if gotLocality != tt.wantLocality {
+				t.Errorf("Allocator.AllocateCoresNearDevices() Locality = %v, want %v", gotLocality, tt.wantLocality)
+			}
+		})
+	}
+}
+
+func TestAllocator_AllocateNearAddresses(t *testing.T) {
+	pcieInfo := pcieinfo.NewPCIEInfoFromDevices([]pcieinfo.PCIEDeviceInfo{
+		{Address: "0000:3b:00.0", NUMANode: 0, NumaNodeAffinityMask: "0xf000f"},
+		{Address: "0000:d8:00.0", NUMANode: 3, NumaNodeAffinityMask: "0xf000f000"},
+	})
+	cpuInfos := testCpuInfos()
+	a := NewAllocator(cpumap.NewCPUMap(cpuInfos), cpuInfos)
+
+	got, gotLocality, err := a.AllocateNearAddresses(pcieInfo, []string{"0000:d8:00.0"}, bitmaputil.New(), 4)
+	if err != nil {
+		t.Fatalf("Allocator.AllocateNearAddresses() error = %v", err)
+	}
+	if want := cpuset.New(12, 13, 28, 29); !got.Machine.Equals(want) || gotLocality != DeviceLocal {
+		t.Errorf("Allocator.AllocateNearAddresses() = %v, %v, want %v, %v", got.Machine, gotLocality, want, DeviceLocal)
+	}
+
+	got, gotLocality, err = a.AllocateCoresNearAddresses(pcieInfo, []string{"0000:3b:00.0"}, bitmaputil.New(), 1)
+	if err != nil {
+		t.Fatalf("Allocator.AllocateCoresNearAddresses() error = %v", err)
+	}
+	if want := cpuset.New(0, 16); !got.Machine.Equals(want) || gotLocality != DeviceLocal {
+		t.Errorf("Allocator.AllocateCoresNearAddresses() = %v, %v, want %v, %v", got.Machine, gotLocality, want, DeviceLocal)
+	}
+
+	if _, _, err := a.AllocateNearAddresses(pcieInfo, []string{"0000:ff:00.0"}, bitmaputil.New(), 2); err == nil {
+		t.Errorf("Allocator.AllocateNearAddresses() error = nil, want an error for an unknown device")
+	}
+}
@@ -58,6 +58,15 @@ func fromBytes(buffer []byte) (bitmap.Bitmap, error) {
 	return out, nil
 }
 
+// List returns the indexes of the bits set, in ascending order.
+func List(bm bitmap.Bitmap) []int {
+	idx := make([]int, 0, bm.Count())
+	bm.Range(func(i uint32) {
+		idx = append(idx, int(i))
+	})
+	return idx
+}
+
 func String(bm bitmap.Bitmap) string {
 	var s string
 	for blkIdx := range bm {
@@ -107,6 +107,39 @@ func TestNewFrom(t *testing.T) {
 	}
 }
 
+func TestList(t *testing.T) {
+	type args struct {
+		bm bitmap.Bitmap
+	}
+	tests := []struct {
+		name string
+		args args
+		want []int
+	}{
+		{
+			name: "empty",
+			args: args{
+				bm: bitmap.Bitmap{},
+			},
+			want: []int{},
+		},
+		{
+			name: "blocks of data",
+			args: args{
+				bm: bitmap.Bitmap{0x5, 0x2},
+			},
+			want: []int{0, 2, 65},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := List(tt.args.bm); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestString(t *testing.T) {
 	type args struct {
 		bm bitmap.Bitmap
@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package cdi
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+// Version is the CDI specification version emitted.
+const Version = "0.6.0"
+
+// AnnotationPrefix prefixes the topology annotations of each device.
+const AnnotationPrefix = "topologyutil.schedmd.com/"
+
+// Spec is a Container Device Interface specification.
+type Spec struct {
+	Version        string          `json:"cdiVersion"`
+	Kind           string          `json:"kind"`
+	Devices        []Device        `json:"devices"`
+	ContainerEdits *ContainerEdits `json:"containerEdits,omitempty"`
+}
+
+type Device struct {
+	Name           string            `json:"name"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	ContainerEdits ContainerEdits    `json:"containerEdits"`
+}
+
+type ContainerEdits struct {
+	Env         []string     `json:"env,omitempty"`
+	DeviceNodes []DeviceNode `json:"deviceNodes,omitempty"`
+}
+
+type DeviceNode struct {
+	Path string `json:"path"`
+}
+
+// Class selects PCIe devices for the specification.
+type Class string
+
+const (
+	// VFIOClass selects devices bound to vfio-pci, of any PCI class.
+	VFIOClass Class = "vfio"
+	// NICClass selects network controllers.
+	NICClass Class = "nic"
+	// NVMeClass selects NVMe controllers.
+	NVMeClass Class = "nvme"
+	// GPUClass selects display controllers.
+	GPUClass Class = "gpu"
+	// AcceleratorClass selects processing accelerators.
+	AcceleratorClass Class = "accelerator"
+)
+
+// Classes lists the known device classes.
+var Classes = []Class{VFIOClass, NICClass, NVMeClass, GPUClass, AcceleratorClass}
+
+// pciClassPrefixes maps device classes to PCI class code prefixes.
+var pciClassPrefixes = map[Class][]string{
+	NICClass:         {"02"},
+	NVMeClass:        {"010802"},
+	GPUClass:         {"0300", "0302"},
+	AcceleratorClass: {"12"},
+}
+
+const vfioDriver = "vfio-pci"
+
+// vfioContainer is the VFIO container node, needed with any VFIO group.
+const vfioContainer = "/dev/vfio/vfio"
+
+// nvidiaControlNodes are the driver-wide nodes NVIDIA GPUs need besides their
+// own /dev/nvidiaN.
+var nvidiaControlNodes = []string{"/dev/nvidiactl", "/dev/nvidia-uvm"}
+
+// Matches returns true when the device belongs to the class.
+func (c Class) Matches(device pcieinfo.PCIEDeviceInfo) bool {
+	if c == VFIOClass {
+		return device.Driver == vfioDriver
+	}
+	class := strings.TrimPrefix(device.Class, "0x")
+	for _, prefix := range pciClassPrefixes[c] {
+		if strings.HasPrefix(class, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSpec returns a specification of kind (e.g. "vendor.com/class") for the
+// devices matching any of the classes, ordered by PCI address. Devices without
+// any device node are skipped.
+func NewSpec(kind string, devices []pcieinfo.PCIEDeviceInfo, classes []Class) (*Spec, error) {
+	for _, class := range classes {
+		if !slices.Contains(Classes, class) {
+			return nil, fmt.Errorf("unknown device class %q", class)
+		}
+	}
+
+	devices = slices.Clone(devices)
+	slices.SortFunc(devices, func(a, b pcieinfo.PCIEDeviceInfo) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+
+	spec := &Spec{
+		Version: Version,
+		Kind:    kind,
+		Devices: []Device{},
+	}
+	for _, device := range devices {
+		if !slices.ContainsFunc(classes, func(c Class) bool { return c.Matches(device) }) {
+			continue
+		}
+		deviceNodes, err := findDeviceNodes(device)
+		if err != nil {
+			return nil, err
+		}
+		if len(deviceNodes) == 0 {
+			continue
+		}
+		spec.Devices = append(spec.Devices, newDevice(device, deviceNodes))
+	}
+	return spec, nil
+}
+
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// newDevice returns the CDI device of the PCIe device. The NUMA node is
+// omitted when it is unknown.
+func newDevice(device pcieinfo.PCIEDeviceInfo, deviceNodes []DeviceNode) Device {
+	envPrefix := "PCIDEVICE_" + invalidEnvChars.ReplaceAllString(strings.ToUpper(device.Address), "_")
+	cdiDevice := Device{
+		Name: strings.ReplaceAll(device.Address, ":", "-"),
+		Annotations: map[string]string{
+			AnnotationPrefix + "address":   device.Address,
+			AnnotationPrefix + "pcie-root": device.PCIERootComplexID,
+		},
+		ContainerEdits: ContainerEdits{
+			Env:         []string{},
+			DeviceNodes: deviceNodes,
+		},
+	}
+	if device.NUMANode != pcieinfo.UnknownNUMANode {
+		numaNode := strconv.Itoa(device.NUMANode)
+		cdiDevice.Annotations[AnnotationPrefix+"numa-node"] = numaNode
+		cdiDevice.ContainerEdits.Env = append(cdiDevice.ContainerEdits.Env, envPrefix+"_NUMA_NODE="+numaNode)
+	}
+	cdiDevice.ContainerEdits.Env = append(cdiDevice.ContainerEdits.Env, envPrefix+"_PCIE_ROOT="+device.PCIERootComplexID)
+	return cdiDevice
+}
+
+// charDevicePatterns maps sysfs class directories under a PCI device to the
+// /dev directory of their character devices.
+var charDevicePatterns = []struct {
+	glob string
+	dir  string
+}{
+	{glob: "nvme/nvme*", dir: "/dev"},
+	{glob: "drm/card*", dir: "/dev/dri"},
+	{glob: "drm/renderD*", dir: "/dev/dri"},
+	{glob: "accel/accel*", dir: "/dev/accel"},
+}
+
+// findDeviceNodes returns the device nodes a container needs to use the
+// device. A vfio-pci device gets its VFIO group and the VFIO container. Other
+// devices get the character devices their driver created, with the device
+// and control nodes of NVIDIA GPUs and the namespace and partition block
+// devices of NVMe controllers.
+func findDeviceNodes(device pcieinfo.PCIEDeviceInfo) ([]DeviceNode, error) {
+	if device.Driver == vfioDriver {
+		if device.IOMMUGroup == "" {
+			return nil, nil
+		}
+		return []DeviceNode{{Path: "/dev/vfio/" + device.IOMMUGroup}, {Path: vfioContainer}}, nil
+	}
+
+	paths := pcieinfo.CharDevices(device.Address)
+	accelerator, ok, err := pcieinfo.NewAccelerator(device)
+	if err != nil {
+		return nil, err
+	}
+	if ok && accelerator.NVIDIAMinor != nil {
+		paths = append(accelerator.DeviceNodes(), paths...)
+		paths = append(paths, nvidiaControlNodes...)
+	}
+	if device.NVMe != nil {
+		for _, namespace := range device.NVMe.Namespaces {
+			paths = append(paths, "/dev/"+namespace.BlockDevice)
+			for _, partition := range namespace.Partitions {
+				paths = append(paths, "/dev/"+partition)
+			}
+		}
+	}
+
+	deviceNodes := []DeviceNode{}
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			deviceNodes = append(deviceNodes, DeviceNode{Path: path})
+		}
+	}
+	return deviceNodes, nil
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package cdi
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+func TestNewSpec(t *testing.T) {
+	hostRoot := t.TempDir()
+	for _, dir := range []string{
+		"sys/bus/pci/devices/0000:01:00.0/nvme/nvme0",
+		"sys/bus/pci/devices/0000:c1:00.0/drm/card1",
+		"sys/bus/pci/devices/0000:c1:00.0/drm/renderD128",
+	} {
+		if err := os.MkdirAll(path.Join(hostRoot, dir), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path.Join(hostRoot, dir, "dev"), []byte("1:2\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	// A GPU without modeset has no DRM nodes, only its NVIDIA minor.
+	nvidiaInfo := path.Join(hostRoot, "proc/driver/nvidia/gpus/0000:41:00.0/information")
+	if err := os.MkdirAll(path.Dir(nvidiaInfo), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(nvidiaInfo, []byte("Model: \t\t NVIDIA A100\nDevice Minor: \t 2\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv("HOST_ROOT", hostRoot)
+
+	devices := []pcieinfo.PCIEDeviceInfo{
+		{Address: "0000:c1:00.0", Class: "0x030000", Driver: "amdgpu", NUMANode: 1, PCIERootComplexID: "pci0000:c0"},
+		{Address: "0000:3b:00.0", Class: "0x020000", Driver: "vfio-pci", IOMMUGroup: "42", PCIERootComplexID: "pci0000:3a"},
+		{Address: "0000:3b:00.1", Class: "0x020000", Driver: "mlx5_core", IOMMUGroup: "43", PCIERootComplexID: "pci0000:3a"},
+		{Address: "0000:01:00.0", Class: "0x010802", Driver: "nvme", NUMANode: pcieinfo.UnknownNUMANode, PCIERootComplexID: "pci0000:00",
+			NVMe: &pcieinfo.NVMeController{
+				Name: "nvme0",
+				Namespaces: []pcieinfo.NVMeNamespace{
+					{Name: "nvme0n1", BlockDevice: "nvme0n1", Partitions: []string{"nvme0n1p1"}},
+				},
+			},
+		},
+		{Address: "0000:41:00.0", VendorID: "10de", Class: "0x030200", Driver: "nvidia", NUMANode: 0, PCIERootComplexID: "pci0000:40"},
+	}
+
+	tests := []struct {
+		name    string
+		classes []Class
+		want    *Spec
+		wantErr bool
+	}{
+		{
+			name:    "vfio",
+			classes: []Class{VFIOClass},
+			want: &Spec{
+				Version: Version,
+				Kind:    "example.com/pci",
+				Devices: []Device{
+					{
+						Name: "0000-3b-00.0",
+						Annotations: map[string]string{
+							AnnotationPrefix + "address":   "0000:3b:00.0",
+							AnnotationPrefix + "numa-node": "0",
+							AnnotationPrefix + "pcie-root": "pci0000:3a",
+						},
+						ContainerEdits: ContainerEdits{
+							Env: []string{
+								"PCIDEVICE_0000_3B_00_0_NUMA_NODE=0",
+								"PCIDEVICE_0000_3B_00_0_PCIE_ROOT=pci0000:3a",
+							},
+							DeviceNodes: []DeviceNode{{Path: "/dev/vfio/42"}, {Path: "/dev/vfio/vfio"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "nvme and gpu",
+			classes: []Class{NVMeClass, GPUClass},
+			want: &Spec{
+				Version: Version,
+				Kind:    "example.com/pci",
+				Devices: []Device{
+					{
+						Name: "0000-01-00.0",
+						Annotations: map[string]string{
+							AnnotationPrefix + "address":   "0000:01:00.0",
+							AnnotationPrefix + "pcie-root": "pci0000:00",
+						},
+						ContainerEdits: ContainerEdits{
+							Env: []string{
+								"PCIDEVICE_0000_01_00_0_PCIE_ROOT=pci0000:00",
+							},
+							DeviceNodes: []DeviceNode{{Path: "/dev/nvme0"}, {Path: "/dev/nvme0n1"}, {Path: "/dev/nvme0n1p1"}},
+						},
+					},
+					{
+						Name: "0000-41-00.0",
+						Annotations: map[string]string{
+							AnnotationPrefix + "address":   "0000:41:00.0",
+							AnnotationPrefix + "numa-node": "0",
+							AnnotationPrefix + "pcie-root": "pci0000:40",
+						},
+						ContainerEdits: ContainerEdits{
+							Env: []string{
+								"PCIDEVICE_0000_41_00_0_NUMA_NODE=0",
+								"PCIDEVICE_0000_41_00_0_PCIE_ROOT=pci0000:40",
+							},
+							DeviceNodes: []DeviceNode{{Path: "/dev/nvidia2"}, {Path: "/dev/nvidiactl"}, {Path: "/dev/nvidia-uvm"}},
+						},
+					},
+					{
+						Name: "0000-c1-00.0",
+						Annotations: map[string]string{
+							AnnotationPrefix + "address":   "0000:c1:00.0",
+							AnnotationPrefix + "numa-node": "1",
+							AnnotationPrefix + "pcie-root": "pci0000:c0",
+						},
+						ContainerEdits: ContainerEdits{
+							Env: []string{
+								"PCIDEVICE_0000_C1_00_0_NUMA_NODE=1",
+								"PCIDEVICE_0000_C1_00_0_PCIE_ROOT=pci0000:c0",
+							},
+							DeviceNodes: []DeviceNode{{Path: "/dev/dri/card1"}, {Path: "/dev/dri/renderD128"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "unknown class",
+			classes: []Class{"fpga"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSpec("example.com/pci", devices, tt.classes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSpec() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
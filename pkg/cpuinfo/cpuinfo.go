@@ -29,6 +29,14 @@ type CPUInfo struct {
 
 	// NUMA Node Affinity Mask
 	NumaNodeAffinityMask string `json:"numaNodeAffinityMask"`
+
+	// ThreadSiblings is the kernel's list of hardware threads sharing this
+	// CPU's core, in cpulist format (e.g. "0,64"). Empty when not exposed.
+	ThreadSiblings string `json:"threadSiblings,omitempty"`
+
+	// L3Siblings is the kernel's list of CPUs sharing this CPU's L3 cache,
+	// in cpulist format. Empty when not exposed.
+	L3Siblings string `json:"l3Siblings,omitempty"`
 }
 
 func GetCPUInfos(options ...CPUInfoOption) ([]CPUInfo, error) {
@@ -117,12 +125,29 @@ func (opts cpuInfoOptions) parseCPUInfo(lines ...string) *CPUInfo {
 		log.Printf("Warning: failed to populate NUMA info for CPU %d: %v", cpuInfo.CpuId, err)
 	}
 
+	cpuInfo.ThreadSiblings = readThreadSiblings(cpuInfo.CpuId)
+	cpuInfo.L3Siblings = readL3Siblings(cpuInfo.CpuId)
+
 	if opts.avoidCPU(cpuInfo.CpuId) {
 		return nil
 	}
 	return cpuInfo
 }
 
+// readThreadSiblings returns the kernel's thread sibling list for the CPU,
+// preferring `core_cpus_list` over the deprecated `thread_siblings_list`.
+func readThreadSiblings(cpuId int) string {
+	for _, name := range []string{"core_cpus_list", "thread_siblings_list"} {
+		filename := HostSys(fmt.Sprintf("devices/system/cpu/cpu%d/topology/%s", cpuId, name))
+		lines, err := ReadLines(filename)
+		if err != nil {
+			continue
+		}
+		return strings.TrimSpace(lines[0])
+	}
+	return ""
+}
+
 func populateNumaInfo(cpuInfo *CPUInfo) error {
 	nodePath := HostSys(fmt.Sprintf("devices/system/cpu/cpu%d", cpuInfo.CpuId))
 	files, err := os.ReadDir(nodePath)
@@ -147,6 +172,31 @@ func populateNumaInfo(cpuInfo *CPUInfo) error {
 	return fmt.Errorf("no numa node found for cpu %d", cpuInfo.CpuId)
 }
 
+// readL3Siblings returns the list of CPUs sharing the CPU's L3 cache.
+func readL3Siblings(cpuId int) string {
+	cachePath := HostSys(fmt.Sprintf("devices/system/cpu/cpu%d/cache", cpuId))
+	files, err := os.ReadDir(cachePath)
+	if err != nil {
+		return ""
+	}
+
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "index") {
+			continue
+		}
+		level, err := ReadFile(filepath.Join(cachePath, file.Name(), "level"))
+		if err != nil || strings.TrimSpace(level) != "3" {
+			continue
+		}
+		lines, err := ReadLines(filepath.Join(cachePath, file.Name(), "shared_cpu_list"))
+		if err != nil {
+			continue
+		}
+		return strings.TrimSpace(lines[0])
+	}
+	return ""
+}
+
 func formatAffinityMask(mask string) string {
 	newMask := strings.ReplaceAll(mask, ",", "")
 	newMask = strings.TrimSpace(newMask)
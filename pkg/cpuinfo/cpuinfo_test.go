@@ -887,3 +887,40 @@ func TestGetCPUInfos(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCPUInfos_topology(t *testing.T) {
+	hostRoot := t.TempDir()
+	files := map[string]string{
+		"proc/cpuinfo": "processor\t: 0\nphysical id\t: 0\ncore id\t\t: 0\n\n" +
+			"processor\t: 1\nphysical id\t: 0\ncore id\t\t: 0\n\n",
+		"sys/devices/system/cpu/cpu0/topology/core_cpus_list":       "0-1\n",
+		"sys/devices/system/cpu/cpu0/topology/thread_siblings_list": "0\n",
+		"sys/devices/system/cpu/cpu1/topology/thread_siblings_list": "0-1\n",
+		"sys/devices/system/cpu/cpu0/cache/index2/level":            "2\n",
+		"sys/devices/system/cpu/cpu0/cache/index2/shared_cpu_list":  "0\n",
+		"sys/devices/system/cpu/cpu0/cache/index3/level":            "3\n",
+		"sys/devices/system/cpu/cpu0/cache/index3/shared_cpu_list":  "0-1\n",
+	}
+	for name, data := range files {
+		filename := path.Join(hostRoot, name)
+		if err := os.MkdirAll(path.Dir(filename), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	t.Setenv("HOST_ROOT", hostRoot)
+
+	got, err := GetCPUInfos()
+	if err != nil {
+		t.Fatalf("GetCPUInfos() error = %v", err)
+	}
+	want := []CPUInfo{
+		{CpuId: 0, SocketId: 0, CoreId: 0, NumaNode: -1, ThreadSiblings: "0-1", L3Siblings: "0-1"},
+		{CpuId: 1, SocketId: 0, CoreId: 0, NumaNode: -1, ThreadSiblings: "0-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCPUInfos() = %v, want %v", got, want)
+	}
+}
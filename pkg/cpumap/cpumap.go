@@ -5,6 +5,7 @@ package cpumap
 
 import (
 	"encoding/json"
+	"fmt"
 	"slices"
 	"sort"
 
@@ -67,10 +68,13 @@ func (cpuMap CPUMap) GetOnesBitmap() bitmap.Bitmap {
 }
 
 // ToAbstractCPUs converts the machine CPU set into an abstract CPU Bitmap.
+// Machine CPUs that are not in the map (e.g. avoided E-Cores) are ignored.
 func (cpuMap CPUMap) ToAbstractCPUs(macCpuSet cpuset.CPUSet) bitmap.Bitmap {
 	absCpus := []int{}
 	for _, idx := range macCpuSet.List() {
-		absCpus = append(absCpus, cpuMap.MachineToAbstract[idx])
+		if absIdx, ok := cpuMap.MachineToAbstract[idx]; ok {
+			absCpus = append(absCpus, absIdx)
+		}
 	}
 	return bitmaputil.New(absCpus...)
 }
@@ -85,6 +89,28 @@ func (cpuMap CPUMap) ToMachineCPUs(absBitmap bitmap.Bitmap) cpuset.CPUSet {
 }
 
 func NewCPUMap(cpuInfos []cpuinfo.CPUInfo) CPUMap {
+	sortCPUInfos(cpuInfos)
+
+	abstractToMachine := make([]cpuset.CPUSet, 0)
+	machineToAbstract := make(map[int]int, len(cpuInfos))
+	coreSiblings, _ := findCoreSiblings(cpuInfos)
+	for _, siblings := range coreSiblings {
+		absIdx := len(abstractToMachine)
+		for _, macIdx := range siblings.List() {
+			machineToAbstract[macIdx] = absIdx
+		}
+		abstractToMachine = append(abstractToMachine, siblings)
+	}
+
+	return CPUMap{
+		AbstractToMachine: abstractToMachine,
+		MachineToAbstract: machineToAbstract,
+	}
+}
+
+// sortCPUInfos orders the CPUs by socket, core and CPU ID, as
+// findCoreSiblings expects.
+func sortCPUInfos(cpuInfos []cpuinfo.CPUInfo) {
 	sort.SliceStable(cpuInfos, func(i, j int) bool {
 		// Align sockets
 		if cpuInfos[i].SocketId != cpuInfos[j].SocketId {
@@ -96,34 +122,84 @@ func NewCPUMap(cpuInfos []cpuinfo.CPUInfo) CPUMap {
 		}
 		return cpuInfos[i].CpuId < cpuInfos[j].CpuId
 	})
+}
 
-	abstractToMachine := make([]cpuset.CPUSet, 0)
+type coreKey struct {
+	socketId int
+	coreId   int
+}
+
+// CheckThreadSiblings describes the cores whose kernel thread siblings are
+// invalid or disagree with their socket and core IDs. NewCPUMap resolves
+// these silently, so callers rebuilding the map often can report them once.
+func CheckThreadSiblings(cpuInfos []cpuinfo.CPUInfo) []string {
+	cpuInfos = slices.Clone(cpuInfos)
+	sortCPUInfos(cpuInfos)
+	_, problems := findCoreSiblings(cpuInfos)
+	return problems
+}
+
+// findCoreSiblings partitions the sorted CPUs into physical cores, in order of
+// first appearance. The kernel's thread sibling list is authoritative when
+// available, because core IDs may only be unique within a die or cluster;
+// otherwise CPUs are grouped by (SocketId, CoreId). It also describes the
+// cores whose thread siblings are invalid or disagree with their IDs.
+func findCoreSiblings(cpuInfos []cpuinfo.CPUInfo) ([]cpuset.CPUSet, []string) {
+	present := make([]int, 0, len(cpuInfos))
+	idSiblings := make(map[coreKey][]int)
 	for _, cpuInfo := range cpuInfos {
-		coreSiblings := findCoreSiblings(cpuInfos, cpuInfo)
-		if !slices.ContainsFunc(abstractToMachine, coreSiblings.Equals) {
-			abstractToMachine = append(abstractToMachine, coreSiblings)
-		}
+		present = append(present, cpuInfo.CpuId)
+		key := coreKey{socketId: cpuInfo.SocketId, coreId: cpuInfo.CoreId}
+		idSiblings[key] = append(idSiblings[key], cpuInfo.CpuId)
 	}
+	presentSet := cpuset.New(present...)
 
-	machineToAbstract := make(map[int]int, len(abstractToMachine))
-	for absIdx, coreSiblings := range abstractToMachine {
-		for _, macIdx := range coreSiblings.List() {
-			machineToAbstract[macIdx] = absIdx
+	coreSiblings := make([]cpuset.CPUSet, 0)
+	problems := []string{}
+	assigned := make(map[int]bool, len(cpuInfos))
+	for _, cpuInfo := range cpuInfos {
+		if assigned[cpuInfo.CpuId] {
+			continue
+		}
+		key := coreKey{socketId: cpuInfo.SocketId, coreId: cpuInfo.CoreId}
+		byId := cpuset.New(idSiblings[key]...)
+		siblings := byId
+		kernel, ok, err := parseThreadSiblings(cpuInfo, presentSet)
+		switch {
+		case err != nil:
+			problems = append(problems, err.Error())
+		case ok:
+			if !kernel.Equals(byId) {
+				problems = append(problems, fmt.Sprintf("CPU %d thread siblings %q disagree with socket %d core %d CPUs %q, using thread siblings",
+					cpuInfo.CpuId, kernel.String(), cpuInfo.SocketId, cpuInfo.CoreId, byId.String()))
+			}
+			siblings = kernel
 		}
-	}
 
-	return CPUMap{
-		AbstractToMachine: abstractToMachine,
-		MachineToAbstract: machineToAbstract,
+		// Guard against asymmetric sibling lists so that every machine CPU
+		// maps to exactly one abstract CPU.
+		group := []int{}
+		for _, cpuId := range siblings.List() {
+			if !assigned[cpuId] {
+				assigned[cpuId] = true
+				group = append(group, cpuId)
+			}
+		}
+		coreSiblings = append(coreSiblings, cpuset.New(group...))
 	}
+	return coreSiblings, problems
 }
 
-func findCoreSiblings(cpuInfos []cpuinfo.CPUInfo, sibling cpuinfo.CPUInfo) cpuset.CPUSet {
-	siblings := []int{}
-	for _, cpuInfo := range cpuInfos {
-		if sibling.SocketId == cpuInfo.SocketId && sibling.CoreId == cpuInfo.CoreId {
-			siblings = append(siblings, cpuInfo.CpuId)
-		}
+// parseThreadSiblings returns the kernel's thread siblings of the CPU,
+// restricted to the reported CPUs. It returns false when the kernel does not
+// report them, and an error when they are invalid.
+func parseThreadSiblings(cpuInfo cpuinfo.CPUInfo, present cpuset.CPUSet) (cpuset.CPUSet, bool, error) {
+	if cpuInfo.ThreadSiblings == "" {
+		return cpuset.New(), false, nil
+	}
+	siblings, err := cpuset.Parse(cpuInfo.ThreadSiblings)
+	if err != nil || !siblings.Contains(cpuInfo.CpuId) {
+		return cpuset.New(), false, fmt.Errorf("ignoring invalid thread siblings %q for CPU %d", cpuInfo.ThreadSiblings, cpuInfo.CpuId)
 	}
-	return cpuset.New(siblings...)
+	return siblings.Intersection(present), true, nil
 }
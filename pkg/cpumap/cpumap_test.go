@@ -4,6 +4,7 @@
 package cpumap
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"reflect"
@@ -1518,3 +1519,213 @@ func TestCPUMap_ToMachineCPUs(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkNewCPUMap(b *testing.B) {
+	// Hosts built in memory, with SMT siblings enumerated the way Linux does
+	// (all first threads, then all second threads), from a workstation up to
+	// hosts larger than any fixture.
+	hosts := []struct {
+		sockets        int
+		coresPerSocket int
+		threadsPerCore int
+	}{
+		{sockets: 1, coresPerSocket: 16, threadsPerCore: 2},
+		{sockets: 1, coresPerSocket: 30, threadsPerCore: 1},
+		{sockets: 2, coresPerSocket: 48, threadsPerCore: 2},
+		{sockets: 2, coresPerSocket: 90, threadsPerCore: 2},
+		{sockets: 2, coresPerSocket: 256, threadsPerCore: 2},
+		{sockets: 2, coresPerSocket: 1024, threadsPerCore: 2},
+	}
+	for _, host := range hosts {
+		cpuInfos := syntheticCpuInfos(host.sockets, host.coresPerSocket, host.threadsPerCore)
+		name := fmt.Sprintf("%dx%dx%d", host.sockets, host.coresPerSocket, host.threadsPerCore)
+		b.Run(name, func(b *testing.B) {
+			benchmarkNewCPUMap(b, cpuInfos)
+		})
+	}
+}
+
+func benchmarkNewCPUMap(b *testing.B, cpuInfos []cpuinfo.CPUInfo) {
+	in := make([]cpuinfo.CPUInfo, len(cpuInfos))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		// NewCPUMap sorts its input, so hand it the original order each time.
+		copy(in, cpuInfos)
+		_ = NewCPUMap(in)
+	}
+}
+
+func syntheticCpuInfos(sockets, coresPerSocket, threadsPerCore int) []cpuinfo.CPUInfo {
+	cpuInfos := []cpuinfo.CPUInfo{}
+	cpuId := 0
+	for range threadsPerCore {
+		for socketId := range sockets {
+			for coreId := range coresPerSocket {
+				cpuInfos = append(cpuInfos, cpuinfo.CPUInfo{
+					CpuId:    cpuId,
+					SocketId: socketId,
+					CoreId:   coreId,
+				})
+				cpuId++
+			}
+		}
+	}
+	return cpuInfos
+}
+
+func TestNewCPUMap_synthetic(t *testing.T) {
+	cpuMap := NewCPUMap(syntheticCpuInfos(2, 4, 2))
+	want := CPUMap{
+		AbstractToMachine: []cpuset.CPUSet{
+			cpuset.New(0, 8),
+			cpuset.New(1, 9),
+			cpuset.New(2, 10),
+			cpuset.New(3, 11),
+			cpuset.New(4, 12),
+			cpuset.New(5, 13),
+			cpuset.New(6, 14),
+			cpuset.New(7, 15),
+		},
+		MachineToAbstract: map[int]int{
+			0: 0, 8: 0,
+			1: 1, 9: 1,
+			2: 2, 10: 2,
+			3: 3, 11: 3,
+			4: 4, 12: 4,
+			5: 5, 13: 5,
+			6: 6, 14: 6,
+			7: 7, 15: 7,
+		},
+	}
+	if !reflect.DeepEqual(cpuMap, want) {
+		t.Errorf("NewCPUMap() = %v, want %v", cpuMap, want)
+	}
+}
+
+func TestNewCPUMap_threadSiblings(t *testing.T) {
+	tests := []struct {
+		name     string
+		cpuInfos []cpuinfo.CPUInfo
+		want     []cpuset.CPUSet
+	}{
+		{
+			name: "core id reused across dies",
+			cpuInfos: []cpuinfo.CPUInfo{
+				{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "0,2"},
+				{CpuId: 1, SocketId: 0, CoreId: 0, ThreadSiblings: "1,3"},
+				{CpuId: 2, SocketId: 0, CoreId: 0, ThreadSiblings: "0,2"},
+				{CpuId: 3, SocketId: 0, CoreId: 0, ThreadSiblings: "1,3"},
+			},
+			want: []cpuset.CPUSet{
+				cpuset.New(0, 2),
+				cpuset.New(1, 3),
+			},
+		},
+		{
+			name: "siblings filtered out",
+			cpuInfos: []cpuinfo.CPUInfo{
+				{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "0-1"},
+				{CpuId: 2, SocketId: 0, CoreId: 1, ThreadSiblings: "2-3"},
+			},
+			want: []cpuset.CPUSet{
+				cpuset.New(0),
+				cpuset.New(2),
+			},
+		},
+		{
+			name: "asymmetric siblings",
+			cpuInfos: []cpuinfo.CPUInfo{
+				{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "0-1"},
+				{CpuId: 1, SocketId: 0, CoreId: 0, ThreadSiblings: "1-2"},
+				{CpuId: 2, SocketId: 0, CoreId: 1, ThreadSiblings: "2"},
+			},
+			want: []cpuset.CPUSet{
+				cpuset.New(0, 1),
+				cpuset.New(2),
+			},
+		},
+		{
+			name: "invalid siblings fall back to ids",
+			cpuInfos: []cpuinfo.CPUInfo{
+				{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "garbage"},
+				{CpuId: 1, SocketId: 0, CoreId: 0, ThreadSiblings: "2"},
+			},
+			want: []cpuset.CPUSet{
+				cpuset.New(0, 1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewCPUMap(tt.cpuInfos)
+			if !reflect.DeepEqual(got.AbstractToMachine, tt.want) {
+				t.Errorf("NewCPUMap().AbstractToMachine = %v, want %v", got.AbstractToMachine, tt.want)
+			}
+			for absIdx, cpuSet := range got.AbstractToMachine {
+				for _, macIdx := range cpuSet.List() {
+					if got.MachineToAbstract[macIdx] != absIdx {
+						t.Errorf("NewCPUMap().MachineToAbstract[%d] = %d, want %d", macIdx, got.MachineToAbstract[macIdx], absIdx)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckThreadSiblings(t *testing.T) {
+	tests := []struct {
+		name     string
+		cpuInfos []cpuinfo.CPUInfo
+		want     []string
+	}{
+		{
+			name: "consistent",
+			cpuInfos: []cpuinfo.CPUInfo{
+				{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "0-1"},
+				{CpuId: 1, SocketId: 0, CoreId: 0, ThreadSiblings: "0-1"},
+			},
+			want: []string{},
+		},
+		{
+			name: "one problem per core",
+			cpuInfos: []cpuinfo.CPUInfo{
+				{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "0,2"},
+				{CpuId: 1, SocketId: 0, CoreId: 0, ThreadSiblings: "1,3"},
+				{CpuId: 2, SocketId: 0, CoreId: 0, ThreadSiblings: "0,2"},
+				{CpuId: 3, SocketId: 0, CoreId: 0, ThreadSiblings: "1,3"},
+			},
+			want: []string{
+				`CPU 0 thread siblings "0,2" disagree with socket 0 core 0 CPUs "0-3", using thread siblings`,
+				`CPU 1 thread siblings "1,3" disagree with socket 0 core 0 CPUs "0-3", using thread siblings`,
+			},
+		},
+		{
+			name: "unsorted",
+			cpuInfos: []cpuinfo.CPUInfo{
+				{CpuId: 3, SocketId: 0, CoreId: 0, ThreadSiblings: "1,3"},
+				{CpuId: 2, SocketId: 0, CoreId: 0, ThreadSiblings: "0,2"},
+				{CpuId: 1, SocketId: 0, CoreId: 0, ThreadSiblings: "1,3"},
+				{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "0,2"},
+			},
+			want: []string{
+				`CPU 0 thread siblings "0,2" disagree with socket 0 core 0 CPUs "0-3", using thread siblings`,
+				`CPU 1 thread siblings "1,3" disagree with socket 0 core 0 CPUs "0-3", using thread siblings`,
+			},
+		},
+		{
+			name: "invalid siblings",
+			cpuInfos: []cpuinfo.CPUInfo{
+				{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "garbage"},
+			},
+			want: []string{`ignoring invalid thread siblings "garbage" for CPU 0`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckThreadSiblings(tt.cpuInfos); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckThreadSiblings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sysfstest builds fake host roots, with a sysfs tree of PCI devices,
+// for tests.
+package sysfstest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Device is a PCI device of a fake sysfs tree.
+type Device struct {
+	// Path is relative to /sys/devices (e.g. "pci0000:00/0000:00:01.0").
+	Path string
+	// Files are the sysfs attributes of the device.
+	Files map[string]string
+	// Links are the symbolic links of the device, relative to /sys.
+	Links map[string]string
+}
+
+// NewHostRoot creates an empty host root, with the PCI bus directory, and
+// points HOST_ROOT at it.
+func NewHostRoot(t testing.TB) string {
+	t.Helper()
+	hostRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(hostRoot, "sys/bus/pci/devices"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	t.Setenv("HOST_ROOT", hostRoot)
+	return hostRoot
+}
+
+// AddDevice adds the device to the sysfs tree of the host root, linking it
+// from the PCI bus, and returns its path.
+func AddDevice(t testing.TB, hostRoot string, device Device) string {
+	t.Helper()
+	devicePath := filepath.Join("sys/devices", device.Path)
+	if err := os.MkdirAll(filepath.Join(hostRoot, devicePath), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	for name, data := range device.Files {
+		WriteFile(t, hostRoot, filepath.Join(devicePath, name), data)
+	}
+	for name, target := range device.Links {
+		Symlink(t, hostRoot, filepath.Join("sys", target), filepath.Join(devicePath, name))
+	}
+	Symlink(t, hostRoot, devicePath, filepath.Join("sys/bus/pci/devices", filepath.Base(device.Path)))
+	return filepath.Join(hostRoot, devicePath)
+}
+
+// WriteFile writes the file name, relative to the host root, creating its
+// directory.
+func WriteFile(t testing.TB, hostRoot, name, data string) {
+	t.Helper()
+	name = filepath.Join(hostRoot, name)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+// Symlink creates a symbolic link from name to target, both relative to the
+// host root, creating the target directory when it is missing.
+func Symlink(t testing.TB, hostRoot, target, name string) {
+	t.Helper()
+	target = filepath.Join(hostRoot, target)
+	name = filepath.Join(hostRoot, name)
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.Symlink(target, name); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package irq
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+// Interrupt is a numbered interrupt from `/proc/interrupts`.
+type Interrupt struct {
+	IRQ int `json:"irq"`
+
+	// Description holds the chip, hardware IRQ and actions (e.g.
+	// "IR-PCI-MSIX-0000:3b:00.0 1-edge mlx5_comp0@pci:0000:3b:00.0").
+	Description string `json:"description"`
+
+	// Count is the number of interrupts serviced, summed over all CPUs.
+	Count uint64 `json:"count"`
+
+	// Affinity is the requested `smp_affinity_list`, EffectiveAffinity the
+	// CPUs actually servicing the interrupt. EffectiveAffinity equals
+	// Affinity when the kernel does not report it.
+	Affinity          cpuset.CPUSet `json:"affinity"`
+	EffectiveAffinity cpuset.CPUSet `json:"effectiveAffinity"`
+}
+
+func (i Interrupt) MarshalJSON() ([]byte, error) {
+	type Alias Interrupt
+	return json.Marshal(&struct {
+		Affinity          string `json:"affinity"`
+		EffectiveAffinity string `json:"effectiveAffinity"`
+		Alias
+	}{
+		Affinity:          i.Affinity.String(),
+		EffectiveAffinity: i.EffectiveAffinity.String(),
+		Alias:             Alias(i),
+	})
+}
+
+// ReadInterrupts reads the numbered interrupts and their CPU affinity,
+// ordered by IRQ.
+func ReadInterrupts() ([]Interrupt, error) {
+	lines, err := cpuinfo.ReadLines(cpuinfo.HostProc("interrupts"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty %s", cpuinfo.HostProc("interrupts"))
+	}
+	// The header names a column for every online CPU.
+	numCPUs := len(strings.Fields(lines[0]))
+
+	interrupts := []Interrupt{}
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		irq, err := strconv.Atoi(strings.TrimSuffix(fields[0], ":"))
+		if err != nil {
+			// Architecture specific interrupts (e.g. "NMI:").
+			continue
+		}
+		interrupt := Interrupt{IRQ: irq}
+		idx := 1
+		for ; idx < len(fields) && idx <= numCPUs; idx++ {
+			count, err := strconv.ParseUint(fields[idx], 10, 64)
+			if err != nil {
+				break
+			}
+			interrupt.Count += count
+		}
+		interrupt.Description = strings.Join(fields[idx:], " ")
+		interrupt.Affinity, interrupt.EffectiveAffinity = readAffinity(irq)
+		interrupts = append(interrupts, interrupt)
+	}
+	slices.SortFunc(interrupts, func(a, b Interrupt) int {
+		return a.IRQ - b.IRQ
+	})
+	return interrupts, nil
+}
+
+// readAffinity reads the requested and effective CPU affinity of the IRQ.
+func readAffinity(irq int) (cpuset.CPUSet, cpuset.CPUSet) {
+	affinity := readCPUList(cpuinfo.HostProc("irq", strconv.Itoa(irq), "smp_affinity_list"))
+	effective := readCPUList(cpuinfo.HostProc("irq", strconv.Itoa(irq), "effective_affinity_list"))
+	if effective.IsEmpty() {
+		effective = affinity
+	}
+	return affinity, effective
+}
+
+func readCPUList(filename string) cpuset.CPUSet {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return cpuset.New()
+	}
+	cpus, err := cpuset.Parse(strings.TrimSpace(string(data)))
+	if err != nil {
+		return cpuset.New()
+	}
+	return cpus
+}
+
+// DeviceIRQs returns the IRQs of the PCI device: its MSI/MSI-X vectors from
+// `msi_irqs/`, otherwise its legacy INTx interrupt.
+func DeviceIRQs(address string) []int {
+	devicePath := cpuinfo.HostSys("bus/pci/devices", address)
+	irqs := []int{}
+	if entries, err := os.ReadDir(filepath.Join(devicePath, "msi_irqs")); err == nil {
+		for _, entry := range entries {
+			if irq, err := strconv.Atoi(entry.Name()); err == nil {
+				irqs = append(irqs, irq)
+			}
+		}
+	}
+	if len(irqs) == 0 {
+		data, err := os.ReadFile(filepath.Join(devicePath, "irq"))
+		if err == nil {
+			if irq, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && irq > 0 {
+				irqs = append(irqs, irq)
+			}
+		}
+	}
+	slices.Sort(irqs)
+	return irqs
+}
+
+// ReadIsolatedCPUs reads the CPUs isolated from the scheduler (`isolcpus`)
+// and from the timer tick (`nohz_full`).
+func ReadIsolatedCPUs() cpuset.CPUSet {
+	isolated := readCPUList(cpuinfo.HostSys("devices/system/cpu/isolated"))
+	nohzFull := readCPUList(cpuinfo.HostSys("devices/system/cpu/nohz_full"))
+	return isolated.Union(nohzFull)
+}
+
+// DeviceReport describes which CPUs service the interrupts of a device.
+type DeviceReport struct {
+	Address  string `json:"address"`
+	NUMANode int    `json:"numaNode"`
+
+	// LocalCPUs are the CPUs local to the device: its `local_cpus`, or the
+	// CPUs of its NUMA node.
+	LocalCPUs cpuset.CPUSet `json:"localCpus"`
+
+	Interrupts []Interrupt `json:"interrupts"`
+
+	// ServicingCPUs are the CPUs effectively servicing the interrupts, and
+	// RemoteCPUs those that are not local to the device.
+	ServicingCPUs cpuset.CPUSet `json:"servicingCpus"`
+	RemoteCPUs    cpuset.CPUSet `json:"remoteCpus"`
+}
+
+// Local returns true when every interrupt is serviced by local CPUs. It is
+// false when the local CPUs are unknown, see LocalityKnown.
+func (r DeviceReport) Local() bool {
+	return r.LocalityKnown() && r.RemoteCPUs.IsEmpty()
+}
+
+// LocalityKnown returns true when the CPUs local to the device are known.
+func (r DeviceReport) LocalityKnown() bool {
+	return !r.LocalCPUs.IsEmpty()
+}
+
+func (r DeviceReport) MarshalJSON() ([]byte, error) {
+	type Alias DeviceReport
+	return json.Marshal(&struct {
+		LocalCPUs     string `json:"localCpus"`
+		ServicingCPUs string `json:"servicingCpus"`
+		RemoteCPUs    string `json:"remoteCpus"`
+		Alias
+	}{
+		LocalCPUs:     r.LocalCPUs.String(),
+		ServicingCPUs: r.ServicingCPUs.String(),
+		RemoteCPUs:    r.RemoteCPUs.String(),
+		Alias:         Alias(r),
+	})
+}
+
+// NewDeviceReports returns a report for every device with interrupts,
+// ordered by address.
+func NewDeviceReports(devices []pcieinfo.PCIEDeviceInfo, interrupts []Interrupt, cpuInfos []cpuinfo.CPUInfo) ([]DeviceReport, error) {
+	byIRQ := make(map[int]Interrupt, len(interrupts))
+	for _, interrupt := range interrupts {
+		byIRQ[interrupt.IRQ] = interrupt
+	}
+
+	devices = slices.Clone(devices)
+	slices.SortFunc(devices, func(a, b pcieinfo.PCIEDeviceInfo) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+
+	reports := []DeviceReport{}
+	for _, device := range devices {
+		irqs := DeviceIRQs(device.Address)
+		if len(irqs) == 0 {
+			continue
+		}
+		localCpus, err := device.LocalCPUs()
+		if err != nil {
+			return nil, err
+		}
+		if localCpus.IsEmpty() && device.NUMANode != pcieinfo.UnknownNUMANode {
+			localCpus = numaNodeCPUs(cpuInfos, device.NUMANode)
+		}
+
+		report := DeviceReport{
+			Address:       device.Address,
+			NUMANode:      device.NUMANode,
+			LocalCPUs:     localCpus,
+			Interrupts:    []Interrupt{},
+			ServicingCPUs: cpuset.New(),
+		}
+		for _, irq := range irqs {
+			interrupt, ok := byIRQ[irq]
+			if !ok {
+				interrupt = Interrupt{IRQ: irq}
+				interrupt.Affinity, interrupt.EffectiveAffinity = readAffinity(irq)
+			}
+			report.Interrupts = append(report.Interrupts, interrupt)
+			report.ServicingCPUs = report.ServicingCPUs.Union(interrupt.EffectiveAffinity)
+		}
+		report.RemoteCPUs = cpuset.New()
+		if !localCpus.IsEmpty() {
+			report.RemoteCPUs = report.ServicingCPUs.Difference(localCpus)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func numaNodeCPUs(cpuInfos []cpuinfo.CPUInfo, numaNode int) cpuset.CPUSet {
+	cpus := []int{}
+	for _, cpuInfo := range cpuInfos {
+		if cpuInfo.NumaNode == numaNode {
+			cpus = append(cpus, cpuInfo.CpuId)
+		}
+	}
+	return cpuset.New(cpus...)
+}
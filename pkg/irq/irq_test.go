@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package irq
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+func writeFiles(t *testing.T, hostRoot string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		filename := filepath.Join(hostRoot, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+}
+
+// newTestHostRoot is a 4 CPU machine with a NIC on node 0 (CPUs 0-1) whose
+// second queue is serviced by CPU 3, and a legacy device on node 1.
+func newTestHostRoot(t *testing.T) {
+	hostRoot := t.TempDir()
+	writeFiles(t, hostRoot, map[string]string{
+		"proc/interrupts": `           CPU0       CPU1       CPU2       CPU3
+   0:         10          0          0          0   IO-APIC   2-edge      timer
+  16:          0          5          0          0   IO-APIC  16-fasteoi   ehci_hcd:usb1
+ 120:        100        200          0          0   IR-PCI-MSIX-0000:3b:00.0    0-edge      mlx5_comp0@pci:0000:3b:00.0
+ 121:          0          0          0         50   IR-PCI-MSIX-0000:3b:00.0    1-edge      mlx5_comp1@pci:0000:3b:00.0
+ NMI:          1          1          1          1   Non-maskable interrupts
+ ERR:          0
+`,
+		"proc/irq/0/smp_affinity_list":                  "0-3\n",
+		"proc/irq/16/smp_affinity_list":                 "2-3\n",
+		"proc/irq/16/effective_affinity_list":           "2\n",
+		"proc/irq/120/smp_affinity_list":                "0-1\n",
+		"proc/irq/120/effective_affinity_list":          "1\n",
+		"proc/irq/121/smp_affinity_list":                "3\n",
+		"sys/bus/pci/devices/0000:3b:00.0/msi_irqs/120": "msix\n",
+		"sys/bus/pci/devices/0000:3b:00.0/msi_irqs/121": "msix\n",
+		"sys/bus/pci/devices/0000:d8:00.0/irq":          "16\n",
+		"sys/bus/pci/devices/0000:d9:00.0/irq":          "0\n",
+		"sys/bus/pci/devices/0000:e1:00.0/irq":          "16\n",
+		"sys/devices/system/cpu/isolated":               "3\n",
+		"sys/devices/system/cpu/nohz_full":              "(null)\n",
+	})
+	t.Setenv("HOST_ROOT", hostRoot)
+}
+
+func testDevices() []pcieinfo.PCIEDeviceInfo {
+	return []pcieinfo.PCIEDeviceInfo{
+		{Address: "0000:d8:00.0", NUMANode: 1, NumaNodeAffinityMask: "0x"},
+		{Address: "0000:3b:00.0", NUMANode: 0, NumaNodeAffinityMask: "0x3"},
+		// A device without interrupts.
+		{Address: "0000:d9:00.0", NUMANode: 1, NumaNodeAffinityMask: "0xc"},
+		// A device without local CPUs on an unknown NUMA node.
+		{Address: "0000:e1:00.0", NUMANode: pcieinfo.UnknownNUMANode, NumaNodeAffinityMask: "0x"},
+	}
+}
+
+func testCpuInfos() []cpuinfo.CPUInfo {
+	return []cpuinfo.CPUInfo{
+		{CpuId: 0, NumaNode: 0},
+		{CpuId: 1, NumaNode: 0},
+		{CpuId: 2, NumaNode: 1},
+		{CpuId: 3, NumaNode: 1},
+	}
+}
+
+func TestReadInterrupts(t *testing.T) {
+	newTestHostRoot(t)
+
+	got, err := ReadInterrupts()
+	if err != nil {
+		t.Fatalf("ReadInterrupts() error = %v", err)
+	}
+	want := []Interrupt{
+		{IRQ: 0, Description: "IO-APIC 2-edge timer", Count: 10, Affinity: cpuset.New(0, 1, 2, 3), EffectiveAffinity: cpuset.New(0, 1, 2, 3)},
+		{IRQ: 16, Description: "IO-APIC 16-fasteoi ehci_hcd:usb1", Count: 5, Affinity: cpuset.New(2, 3), EffectiveAffinity: cpuset.New(2)},
+		{IRQ: 120, Description: "IR-PCI-MSIX-0000:3b:00.0 0-edge mlx5_comp0@pci:0000:3b:00.0", Count: 300, Affinity: cpuset.New(0, 1), EffectiveAffinity: cpuset.New(1)},
+		{IRQ: 121, Description: "IR-PCI-MSIX-0000:3b:00.0 1-edge mlx5_comp1@pci:0000:3b:00.0", Count: 50, Affinity: cpuset.New(3), EffectiveAffinity: cpuset.New(3)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadInterrupts() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].IRQ != want[i].IRQ || got[i].Description != want[i].Description || got[i].Count != want[i].Count ||
+			!got[i].Affinity.Equals(want[i].Affinity) || !got[i].EffectiveAffinity.Equals(want[i].EffectiveAffinity) {
+			t.Errorf("ReadInterrupts()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDeviceIRQs(t *testing.T) {
+	newTestHostRoot(t)
+
+	tests := []struct {
+		address string
+		want    []int
+	}{
+		{address: "0000:3b:00.0", want: []int{120, 121}},
+		{address: "0000:d8:00.0", want: []int{16}},
+		{address: "0000:d9:00.0", want: []int{}},
+		{address: "0000:00:00.0", want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if got := DeviceIRQs(tt.address); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeviceIRQs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDeviceReports(t *testing.T) {
+	newTestHostRoot(t)
+
+	interrupts, err := ReadInterrupts()
+	if err != nil {
+		t.Fatalf("ReadInterrupts() error = %v", err)
+	}
+	// A CPU of an unknown NUMA node is not local to devices of an unknown node.
+	cpuInfos := append(testCpuInfos(), cpuinfo.CPUInfo{CpuId: 4, NumaNode: pcieinfo.UnknownNUMANode})
+	reports, err := NewDeviceReports(testDevices(), interrupts, cpuInfos)
+	if err != nil {
+		t.Fatalf("NewDeviceReports() error = %v", err)
+	}
+
+	tests := []struct {
+		address   string
+		localCpus cpuset.CPUSet
+		servicing cpuset.CPUSet
+		remote    cpuset.CPUSet
+		local     bool
+	}{
+		{address: "0000:3b:00.0", localCpus: cpuset.New(0, 1), servicing: cpuset.New(1, 3), remote: cpuset.New(3), local: false},
+		// Without local_cpus, the CPUs of the NUMA node are local.
+		{address: "0000:d8:00.0", localCpus: cpuset.New(2, 3), servicing: cpuset.New(2), remote: cpuset.New(), local: true},
+		// Without local_cpus or NUMA node, no CPU is known to be remote, nor
+		// the device to be local.
+		{address: "0000:e1:00.0", localCpus: cpuset.New(), servicing: cpuset.New(2), remote: cpuset.New(), local: false},
+	}
+	if len(reports) != len(tests) {
+		t.Fatalf("NewDeviceReports() = %d reports, want %d", len(reports), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got := reports[i]
+			if got.Address != tt.address || !got.LocalCPUs.Equals(tt.localCpus) || !got.ServicingCPUs.Equals(tt.servicing) ||
+				!got.RemoteCPUs.Equals(tt.remote) || got.Local() != tt.local {
+				t.Errorf("NewDeviceReports()[%d] = %+v, want %+v", i, got, tt)
+			}
+		})
+	}
+}
+
+func TestReadIsolatedCPUs(t *testing.T) {
+	newTestHostRoot(t)
+
+	if got, want := ReadIsolatedCPUs(), cpuset.New(3); !got.Equals(want) {
+		t.Errorf("ReadIsolatedCPUs() = %v, want %v", got, want)
+	}
+}
+
+func TestDeviceReport_MarshalJSON(t *testing.T) {
+	report := DeviceReport{
+		Address:   "0000:3b:00.0",
+		LocalCPUs: cpuset.New(0, 1, 2, 3),
+		Interrupts: []Interrupt{
+			{IRQ: 120, Count: 300, Affinity: cpuset.New(0, 1), EffectiveAffinity: cpuset.New(1)},
+		},
+		ServicingCPUs: cpuset.New(1),
+		RemoteCPUs:    cpuset.New(),
+	}
+	got, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"localCpus":"0-3","servicingCpus":"1","remoteCpus":"","address":"0000:3b:00.0","numaNode":0,` +
+		`"interrupts":[{"affinity":"0-1","effectiveAffinity":"1","irq":120,"description":"","count":300}]}`
+	if string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
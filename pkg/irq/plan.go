@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package irq
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/utils/cpuset"
+)
+
+// Assignment is a proposed CPU affinity for an interrupt.
+type Assignment struct {
+	IRQ     int           `json:"irq"`
+	Address string        `json:"address"`
+	CPUs    cpuset.CPUSet `json:"cpus"`
+
+	// Remote is true when no local CPU was available to the device.
+	Remote bool `json:"remote,omitempty"`
+}
+
+func (a Assignment) MarshalJSON() ([]byte, error) {
+	type Alias Assignment
+	return json.Marshal(&struct {
+		CPUs string `json:"cpus"`
+		Alias
+	}{
+		CPUs:  a.CPUs.String(),
+		Alias: Alias(a),
+	})
+}
+
+// String formats the assignment as the shell command applying it.
+func (a Assignment) String() string {
+	return fmt.Sprintf("echo %s > /proc/irq/%d/smp_affinity_list", a.CPUs, a.IRQ)
+}
+
+// Plan proposes a CPU for every interrupt of the devices, never using the
+// avoided CPUs (e.g. isolated or job CPUs). Interrupts are spread over the
+// least loaded CPUs local to their device, falling back to any allowed CPU.
+//
+// Note that the kernel manages the affinity of some interrupts (e.g. NVMe
+// queues) itself and rejects changes to them.
+func Plan(reports []DeviceReport, allCPUs cpuset.CPUSet, avoid cpuset.CPUSet) ([]Assignment, error) {
+	allowed := allCPUs.Difference(avoid)
+	if allowed.IsEmpty() {
+		return nil, fmt.Errorf("no CPUs left for interrupts after avoiding %s", avoid)
+	}
+
+	load := make(map[int]int, allowed.Size())
+	assignments := []Assignment{}
+	for _, report := range reports {
+		candidates := report.LocalCPUs.Intersection(allowed)
+		remote := candidates.IsEmpty()
+		if remote {
+			candidates = allowed
+		}
+		for _, interrupt := range report.Interrupts {
+			cpu := leastLoaded(candidates, load)
+			load[cpu]++
+			assignments = append(assignments, Assignment{
+				IRQ:     interrupt.IRQ,
+				Address: report.Address,
+				CPUs:    cpuset.New(cpu),
+				Remote:  remote,
+			})
+		}
+	}
+	return assignments, nil
+}
+
+// leastLoaded returns the CPU with the fewest interrupts, the lowest on ties.
+func leastLoaded(cpus cpuset.CPUSet, load map[int]int) int {
+	best := -1
+	for _, cpu := range cpus.List() {
+		if best < 0 || load[cpu] < load[best] {
+			best = cpu
+		}
+	}
+	return best
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package irq
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/utils/cpuset"
+)
+
+func TestPlan(t *testing.T) {
+	nic := DeviceReport{
+		Address:    "0000:3b:00.0",
+		LocalCPUs:  cpuset.New(0, 1, 2, 3),
+		Interrupts: []Interrupt{{IRQ: 120}, {IRQ: 121}, {IRQ: 122}},
+	}
+	gpu := DeviceReport{
+		Address:    "0000:d8:00.0",
+		LocalCPUs:  cpuset.New(4, 5, 6, 7),
+		Interrupts: []Interrupt{{IRQ: 200}},
+	}
+
+	tests := []struct {
+		name    string
+		reports []DeviceReport
+		avoid   cpuset.CPUSet
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "spread over local CPUs",
+			reports: []DeviceReport{nic, gpu},
+			avoid:   cpuset.New(),
+			want: []string{
+				"echo 0 > /proc/irq/120/smp_affinity_list",
+				"echo 1 > /proc/irq/121/smp_affinity_list",
+				"echo 2 > /proc/irq/122/smp_affinity_list",
+				"echo 4 > /proc/irq/200/smp_affinity_list",
+			},
+		},
+		{
+			name:    "avoid job CPUs",
+			reports: []DeviceReport{nic},
+			avoid:   cpuset.New(1, 2, 3),
+			want: []string{
+				"echo 0 > /proc/irq/120/smp_affinity_list",
+				"echo 0 > /proc/irq/121/smp_affinity_list",
+				"echo 0 > /proc/irq/122/smp_affinity_list",
+			},
+		},
+		{
+			name:    "fall back to remote CPUs",
+			reports: []DeviceReport{gpu},
+			avoid:   cpuset.New(1, 2, 3, 4, 5, 6, 7),
+			want: []string{
+				"echo 0 > /proc/irq/200/smp_affinity_list",
+			},
+		},
+		{
+			name:    "no CPUs left",
+			reports: []DeviceReport{nic},
+			avoid:   cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+			wantErr: true,
+		},
+	}
+	allCPUs := cpuset.New(0, 1, 2, 3, 4, 5, 6, 7)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignments, err := Plan(tt.reports, allCPUs, tt.avoid)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Plan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(assignments) != len(tt.want) {
+				t.Fatalf("Plan() = %v, want %v", assignments, tt.want)
+			}
+			for i, assignment := range assignments {
+				if got := assignment.String(); got != tt.want[i] {
+					t.Errorf("Plan()[%d] = %v, want %v", i, got, tt.want[i])
+				}
+				if !assignment.CPUs.Intersection(tt.avoid).IsEmpty() {
+					t.Errorf("Plan()[%d] uses avoided CPUs %v", i, assignment.CPUs)
+				}
+			}
+		})
+	}
+}
+
+func TestAssignment_MarshalJSON(t *testing.T) {
+	got, err := json.Marshal(Assignment{IRQ: 120, Address: "0000:3b:00.0", CPUs: cpuset.New(0, 2)})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"cpus":"0,2","irq":120,"address":"0000:3b:00.0"}`; string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
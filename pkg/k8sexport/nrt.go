@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package k8sexport
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+)
+
+// ObjectMeta is the subset of Kubernetes object metadata that is exported.
+type ObjectMeta struct {
+	Name string `json:"name"`
+}
+
+// NodeResourceTopology is a topology.node.k8s.io/v1alpha2 NodeResourceTopology.
+type NodeResourceTopology struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Attributes []Attribute `json:"attributes,omitempty"`
+	Zones      []Zone      `json:"zones"`
+}
+
+// Zone is a NUMA node or L3 cache of the NodeResourceTopology.
+type Zone struct {
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Parent     string         `json:"parent,omitempty"`
+	Costs      []CostInfo     `json:"costs,omitempty"`
+	Attributes []Attribute    `json:"attributes,omitempty"`
+	Resources  []ResourceInfo `json:"resources,omitempty"`
+}
+
+type CostInfo struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+type Attribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type ResourceInfo struct {
+	Name        string `json:"name"`
+	Capacity    string `json:"capacity"`
+	Allocatable string `json:"allocatable"`
+	Available   string `json:"available"`
+}
+
+// NewNodeResourceTopology groups the CPUs into NUMA node zones and, when the
+// kernel exposes cache topology, L3 cache zones parented by their NUMA node.
+// CPUs whose NUMA node is unknown are left out, as they belong to no zone.
+func NewNodeResourceTopology(nodeName string, cpuInfos []cpuinfo.CPUInfo) NodeResourceTopology {
+	numaCpus := make(map[int][]int)
+	l3Cpus := make(map[int]map[string][]int)
+	for _, cpuInfo := range cpuInfos {
+		if cpuInfo.NumaNode < 0 {
+			continue
+		}
+		numaCpus[cpuInfo.NumaNode] = append(numaCpus[cpuInfo.NumaNode], cpuInfo.CpuId)
+		if cpuInfo.L3Siblings == "" {
+			continue
+		}
+		if l3Cpus[cpuInfo.NumaNode] == nil {
+			l3Cpus[cpuInfo.NumaNode] = make(map[string][]int)
+		}
+		l3Cpus[cpuInfo.NumaNode][cpuInfo.L3Siblings] = append(l3Cpus[cpuInfo.NumaNode][cpuInfo.L3Siblings], cpuInfo.CpuId)
+	}
+
+	numaNodes := make([]int, 0, len(numaCpus))
+	for numaNode := range numaCpus {
+		numaNodes = append(numaNodes, numaNode)
+	}
+	slices.Sort(numaNodes)
+
+	zones := []Zone{}
+	for _, numaNode := range numaNodes {
+		cpuSet := cpuset.New(numaCpus[numaNode]...)
+		nodeZone := Zone{
+			Name:       zoneName(numaNode),
+			Type:       "Node",
+			Costs:      readNumaCosts(numaNode),
+			Attributes: []Attribute{{Name: "cpus", Value: cpuSet.String()}},
+			Resources:  []ResourceInfo{cpuResource(cpuSet.Size())},
+		}
+		zones = append(zones, nodeZone)
+
+		// Order the L3 caches by their first CPU.
+		l3Sets := []cpuset.CPUSet{}
+		for _, cpus := range l3Cpus[numaNode] {
+			l3Sets = append(l3Sets, cpuset.New(cpus...))
+		}
+		slices.SortFunc(l3Sets, func(a, b cpuset.CPUSet) int {
+			return a.List()[0] - b.List()[0]
+		})
+		for idx, l3Set := range l3Sets {
+			zones = append(zones, Zone{
+				Name:       fmt.Sprintf("%s-l3-%d", nodeZone.Name, idx),
+				Type:       "L3Cache",
+				Parent:     nodeZone.Name,
+				Attributes: []Attribute{{Name: "cpus", Value: l3Set.String()}},
+				Resources:  []ResourceInfo{cpuResource(l3Set.Size())},
+			})
+		}
+	}
+
+	return NodeResourceTopology{
+		APIVersion: "topology.node.k8s.io/v1alpha2",
+		Kind:       "NodeResourceTopology",
+		Metadata:   ObjectMeta{Name: nodeName},
+		Zones:      zones,
+	}
+}
+
+func zoneName(numaNode int) string {
+	return "node-" + strconv.Itoa(numaNode)
+}
+
+func cpuResource(numCPUs int) ResourceInfo {
+	quantity := strconv.Itoa(numCPUs)
+	return ResourceInfo{
+		Name:        "cpu",
+		Capacity:    quantity,
+		Allocatable: quantity,
+		Available:   quantity,
+	}
+}
+
+// readNumaCosts reads the distances from the NUMA node to the other nodes.
+func readNumaCosts(numaNode int) []CostInfo {
+	lines, err := cpuinfo.ReadLines(cpuinfo.HostSys(fmt.Sprintf("devices/system/node/node%d/distance", numaNode)))
+	if err != nil {
+		return nil
+	}
+	costs := []CostInfo{}
+	for idx, field := range strings.Fields(lines[0]) {
+		distance, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil
+		}
+		costs = append(costs, CostInfo{Name: zoneName(idx), Value: distance})
+	}
+	return costs
+}
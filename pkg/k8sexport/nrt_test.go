@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package k8sexport
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+)
+
+func TestNewNodeResourceTopology(t *testing.T) {
+	hostRoot := t.TempDir()
+	for node, distance := range map[string]string{"node0": "10 21\n", "node1": "21 10\n"} {
+		nodePath := path.Join(hostRoot, "sys/devices/system/node", node)
+		if err := os.MkdirAll(nodePath, 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path.Join(nodePath, "distance"), []byte(distance), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	t.Setenv("HOST_ROOT", hostRoot)
+
+	cpuInfos := []cpuinfo.CPUInfo{
+		{CpuId: 0, NumaNode: 0, L3Siblings: "0-1"},
+		{CpuId: 1, NumaNode: 0, L3Siblings: "0-1"},
+		{CpuId: 2, NumaNode: 0, L3Siblings: "2-3"},
+		{CpuId: 3, NumaNode: 0, L3Siblings: "2-3"},
+		{CpuId: 4, SocketId: 1, NumaNode: 1},
+		{CpuId: 5, SocketId: 1, NumaNode: 1},
+		// No zone is named "node--1".
+		{CpuId: 6, SocketId: 1, NumaNode: -1},
+	}
+	got := NewNodeResourceTopology("node0", cpuInfos)
+	want := NodeResourceTopology{
+		APIVersion: "topology.node.k8s.io/v1alpha2",
+		Kind:       "NodeResourceTopology",
+		Metadata:   ObjectMeta{Name: "node0"},
+		Zones: []Zone{
+			{
+				Name:       "node-0",
+				Type:       "Node",
+				Costs:      []CostInfo{{Name: "node-0", Value: 10}, {Name: "node-1", Value: 21}},
+				Attributes: []Attribute{{Name: "cpus", Value: "0-3"}},
+				Resources:  []ResourceInfo{{Name: "cpu", Capacity: "4", Allocatable: "4", Available: "4"}},
+			},
+			{
+				Name:       "node-0-l3-0",
+				Type:       "L3Cache",
+				Parent:     "node-0",
+				Attributes: []Attribute{{Name: "cpus", Value: "0-1"}},
+				Resources:  []ResourceInfo{{Name: "cpu", Capacity: "2", Allocatable: "2", Available: "2"}},
+			},
+			{
+				Name:       "node-0-l3-1",
+				Type:       "L3Cache",
+				Parent:     "node-0",
+				Attributes: []Attribute{{Name: "cpus", Value: "2-3"}},
+				Resources:  []ResourceInfo{{Name: "cpu", Capacity: "2", Allocatable: "2", Available: "2"}},
+			},
+			{
+				Name:       "node-1",
+				Type:       "Node",
+				Costs:      []CostInfo{{Name: "node-0", Value: 21}, {Name: "node-1", Value: 10}},
+				Attributes: []Attribute{{Name: "cpus", Value: "4-5"}},
+				Resources:  []ResourceInfo{{Name: "cpu", Capacity: "2", Allocatable: "2", Available: "2"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewNodeResourceTopology() = %+v, want %+v", got, want)
+	}
+}
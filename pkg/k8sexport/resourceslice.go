@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package k8sexport
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+// MaxDevicesPerSlice is the API limit of devices in a single ResourceSlice.
+const MaxDevicesPerSlice = 128
+
+// PCIERootAttribute is the standard attribute identifying the PCIe root
+// complex of a device.
+const PCIERootAttribute = "resource.kubernetes.io/pcieRoot"
+
+// ResourceSlice is a resource.k8s.io/v1beta1 ResourceSlice.
+type ResourceSlice struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   ObjectMeta        `json:"metadata"`
+	Spec       ResourceSliceSpec `json:"spec"`
+}
+
+type ResourceSliceSpec struct {
+	Driver   string       `json:"driver"`
+	Pool     ResourcePool `json:"pool"`
+	NodeName string       `json:"nodeName"`
+	Devices  []Device     `json:"devices"`
+}
+
+type ResourcePool struct {
+	Name               string `json:"name"`
+	Generation         int64  `json:"generation"`
+	ResourceSliceCount int64  `json:"resourceSliceCount"`
+}
+
+type Device struct {
+	Name  string      `json:"name"`
+	Basic BasicDevice `json:"basic"`
+}
+
+type BasicDevice struct {
+	Attributes map[string]DeviceAttribute `json:"attributes"`
+}
+
+// DeviceAttribute holds exactly one typed value.
+type DeviceAttribute struct {
+	IntValue    *int64  `json:"int,omitempty"`
+	StringValue *string `json:"string,omitempty"`
+}
+
+func intAttribute(v int) DeviceAttribute {
+	i := int64(v)
+	return DeviceAttribute{IntValue: &i}
+}
+
+func stringAttribute(v string) DeviceAttribute {
+	return DeviceAttribute{StringValue: &v}
+}
+
+// bridgeClass is the PCI base class of bridges.
+const bridgeClass = "06"
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// DeviceName converts a PCI address (e.g. "0000:3b:00.0") into a valid device
+// name (e.g. "pci-0000-3b-00-0").
+func DeviceName(address string) string {
+	return "pci-" + strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(address), "-"), "-")
+}
+
+// NewResourceSlices publishes the PCIe devices of the node for the driver,
+// ordered by PCI address and split into slices of MaxDevicesPerSlice. Bridges,
+// including host bridges and switch ports, cannot be allocated and are left
+// out.
+func NewResourceSlices(nodeName, driver string, devices []pcieinfo.PCIEDeviceInfo) []ResourceSlice {
+	devices = slices.DeleteFunc(slices.Clone(devices), pcieinfo.ByClass(bridgeClass))
+	slices.SortFunc(devices, func(a, b pcieinfo.PCIEDeviceInfo) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+
+	sliceCount := max((len(devices)+MaxDevicesPerSlice-1)/MaxDevicesPerSlice, 1)
+	resourceSlices := make([]ResourceSlice, 0, sliceCount)
+	for idx := range sliceCount {
+		chunk := devices[idx*MaxDevicesPerSlice : min((idx+1)*MaxDevicesPerSlice, len(devices))]
+		resourceDevices := make([]Device, 0, len(chunk))
+		for _, device := range chunk {
+			resourceDevices = append(resourceDevices, newDevice(device))
+		}
+		resourceSlices = append(resourceSlices, ResourceSlice{
+			APIVersion: "resource.k8s.io/v1beta1",
+			Kind:       "ResourceSlice",
+			Metadata: ObjectMeta{
+				Name: fmt.Sprintf("%s-%s-%d", nodeName, invalidNameChars.ReplaceAllString(strings.ToLower(driver), "-"), idx),
+			},
+			Spec: ResourceSliceSpec{
+				Driver: driver,
+				Pool: ResourcePool{
+					Name:               nodeName,
+					Generation:         1,
+					ResourceSliceCount: int64(sliceCount),
+				},
+				NodeName: nodeName,
+				Devices:  resourceDevices,
+			},
+		})
+	}
+	return resourceSlices
+}
+
+func newDevice(device pcieinfo.PCIEDeviceInfo) Device {
+	attributes := map[string]DeviceAttribute{
+		PCIERootAttribute: stringAttribute(device.PCIERootComplexID),
+		"address":         stringAttribute(device.Address),
+		"vendorId":        stringAttribute(device.VendorID),
+		"deviceId":        stringAttribute(device.DeviceID),
+		"class":           stringAttribute(device.Class),
+	}
+	optional := map[string]string{
+		"subsystemVendorId": device.SubVendorID,
+		"subsystemDeviceId": device.SubDeviceID,
+		"driver":            device.Driver,
+	}
+	for name, value := range optional {
+		if value != "" {
+			attributes[name] = stringAttribute(value)
+		}
+	}
+	if device.NUMANode != pcieinfo.UnknownNUMANode {
+		attributes["numaNode"] = intAttribute(device.NUMANode)
+	}
+	return Device{
+		Name:  DeviceName(device.Address),
+		Basic: BasicDevice{Attributes: attributes},
+	}
+}
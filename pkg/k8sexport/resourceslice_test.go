@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package k8sexport
+
+import (
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+func TestNewResourceSlices(t *testing.T) {
+	devices := []pcieinfo.PCIEDeviceInfo{
+		{
+			Address:           "0000:3b:00.0",
+			VendorID:          "10de",
+			DeviceID:          "20b0",
+			Class:             "0x030200",
+			Driver:            "nvidia",
+			PCIERootComplexID: "pci0000:3a",
+			NUMANode:          0,
+		},
+		// Bridges are not published.
+		{Address: "0000:00:00.0", VendorID: "8086", DeviceID: "09a2", Class: "0x060000", PCIERootComplexID: "pci0000:00"},
+		{Address: "0000:3a:00.0", VendorID: "8086", DeviceID: "347a", Class: "0x060400", PCIERootComplexID: "pci0000:3a"},
+	}
+	got := NewResourceSlices("node0", "gpu.example.com", devices)
+	if len(got) != 1 {
+		t.Fatalf("NewResourceSlices() = %d slices, want 1", len(got))
+	}
+	data, err := yaml.Marshal(got[0])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `apiVersion: resource.k8s.io/v1beta1
+kind: ResourceSlice
+metadata:
+  name: node0-gpu-example-com-0
+spec:
+  devices:
+  - basic:
+      attributes:
+        address:
+          string: 0000:3b:00.0
+        class:
+          string: "0x030200"
+        deviceId:
+          string: 20b0
+        driver:
+          string: nvidia
+        numaNode:
+          int: 0
+        resource.kubernetes.io/pcieRoot:
+          string: pci0000:3a
+        vendorId:
+          string: 10de
+    name: pci-0000-3b-00-0
+  driver: gpu.example.com
+  nodeName: node0
+  pool:
+    generation: 1
+    name: node0
+    resourceSliceCount: 1
+`
+	if string(data) != want {
+		t.Errorf("NewResourceSlices() = %v, want %v", string(data), want)
+	}
+}
+
+func TestNewResourceSlices_split(t *testing.T) {
+	devices := []pcieinfo.PCIEDeviceInfo{}
+	for idx := range MaxDevicesPerSlice + 1 {
+		devices = append(devices, pcieinfo.PCIEDeviceInfo{Address: fmt.Sprintf("0000:%02x:00.0", idx)})
+	}
+	got := NewResourceSlices("node0", "gpu.example.com", devices)
+	if len(got) != 2 {
+		t.Fatalf("NewResourceSlices() = %d slices, want 2", len(got))
+	}
+	if n := len(got[0].Spec.Devices); n != MaxDevicesPerSlice {
+		t.Errorf("NewResourceSlices()[0] = %d devices, want %d", n, MaxDevicesPerSlice)
+	}
+	if n := len(got[1].Spec.Devices); n != 1 {
+		t.Errorf("NewResourceSlices()[1] = %d devices, want 1", n)
+	}
+	if count := got[1].Spec.Pool.ResourceSliceCount; count != 2 {
+		t.Errorf("NewResourceSlices()[1] ResourceSliceCount = %d, want 2", count)
+	}
+}
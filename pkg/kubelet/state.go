@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package kubelet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+)
+
+// CPUManagerState is the checkpoint of the kubelet CPU manager.
+type CPUManagerState struct {
+	PolicyName    string                       `json:"policyName"`
+	DefaultCPUSet string                       `json:"defaultCpuSet"`
+	Entries       map[string]map[string]string `json:"entries,omitempty"`
+	Checksum      uint64                       `json:"checksum"`
+}
+
+// MemoryBlock is a block of memory assigned to a container.
+type MemoryBlock struct {
+	NUMAAffinity []int  `json:"numaAffinity"`
+	Type         string `json:"type"`
+	Size         uint64 `json:"size"`
+}
+
+// MemoryManagerState is the checkpoint of the kubelet memory manager. The
+// machine state is not decoded.
+type MemoryManagerState struct {
+	PolicyName string                              `json:"policyName"`
+	Entries    map[string]map[string][]MemoryBlock `json:"entries,omitempty"`
+	Checksum   uint64                              `json:"checksum"`
+}
+
+// ReadCPUManagerState reads the kubelet CPU manager checkpoint.
+func ReadCPUManagerState() (*CPUManagerState, error) {
+	state := &CPUManagerState{}
+	if err := readState(cpuinfo.HostRoot("var/lib/kubelet/cpu_manager_state"), state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// ReadMemoryManagerState reads the kubelet memory manager checkpoint.
+func ReadMemoryManagerState() (*MemoryManagerState, error) {
+	state := &MemoryManagerState{}
+	if err := readState(cpuinfo.HostRoot("var/lib/kubelet/memory_manager_state"), state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func readState(filename string, state any) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return nil
+}
+
+// Report describes how kubelet assigned the node to containers.
+type Report struct {
+	CPUPolicyName    string                `json:"cpuPolicyName"`
+	MemoryPolicyName string                `json:"memoryPolicyName,omitempty"`
+	DefaultCPUSet    string                `json:"defaultCpuSet"`
+	Containers       []ContainerAssignment `json:"containers"`
+}
+
+// ContainerAssignment is the placement of a single container.
+type ContainerAssignment struct {
+	PodUID        string `json:"podUid"`
+	ContainerName string `json:"containerName"`
+
+	// CPUs is the machine CPU set assigned to the container.
+	CPUs string `json:"cpus"`
+
+	// AbstractCPUs is the set of abstract CPUs of the CPUMap touched.
+	AbstractCPUs string `json:"abstractCpus"`
+
+	Sockets   []int `json:"sockets"`
+	NUMANodes []int `json:"numaNodes"`
+
+	// Cores lists the physical cores touched, as the machine CPUs of each
+	// abstract CPU (e.g. "2,10").
+	Cores []string `json:"cores"`
+
+	// MemoryNUMANodes is the NUMA affinity of the assigned memory.
+	MemoryNUMANodes []int `json:"memoryNumaNodes,omitempty"`
+
+	// SplitsSMT is true when some core is only partially assigned.
+	SplitsSMT bool `json:"splitsSmt"`
+
+	// CrossesNUMA is true when the CPUs and memory span several NUMA nodes.
+	CrossesNUMA bool `json:"crossesNuma"`
+}
+
+// NewReport maps the container assignments of the checkpoints onto the
+// topology. The memory manager state is optional.
+func NewReport(cpuState *CPUManagerState, memState *MemoryManagerState, cpuInfos []cpuinfo.CPUInfo, cpuMap cpumap.CPUMap) (Report, error) {
+	byCpuId := make(map[int]cpuinfo.CPUInfo, len(cpuInfos))
+	for _, cpuInfo := range cpuInfos {
+		byCpuId[cpuInfo.CpuId] = cpuInfo
+	}
+
+	report := Report{
+		CPUPolicyName: cpuState.PolicyName,
+		DefaultCPUSet: cpuState.DefaultCPUSet,
+		Containers:    []ContainerAssignment{},
+	}
+	if memState != nil {
+		report.MemoryPolicyName = memState.PolicyName
+	}
+
+	for podUID, containers := range cpuState.Entries {
+		for containerName, cpus := range containers {
+			cpuSet, err := cpuset.Parse(cpus)
+			if err != nil {
+				return Report{}, fmt.Errorf("invalid CPU set %q for container %s/%s: %w", cpus, podUID, containerName, err)
+			}
+			assignment := newContainerAssignment(cpuSet, byCpuId, cpuMap)
+			assignment.PodUID = podUID
+			assignment.ContainerName = containerName
+			if memState != nil {
+				assignment.MemoryNUMANodes = memoryNUMANodes(memState.Entries[podUID][containerName])
+			}
+			numaNodes := append(slices.Clone(assignment.NUMANodes), assignment.MemoryNUMANodes...)
+			slices.Sort(numaNodes)
+			assignment.CrossesNUMA = len(slices.Compact(numaNodes)) > 1
+			report.Containers = append(report.Containers, assignment)
+		}
+	}
+
+	slices.SortFunc(report.Containers, func(a, b ContainerAssignment) int {
+		if c := strings.Compare(a.PodUID, b.PodUID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ContainerName, b.ContainerName)
+	})
+	return report, nil
+}
+
+func newContainerAssignment(cpuSet cpuset.CPUSet, byCpuId map[int]cpuinfo.CPUInfo, cpuMap cpumap.CPUMap) ContainerAssignment {
+	sockets := []int{}
+	numaNodes := []int{}
+	for _, cpuId := range cpuSet.List() {
+		cpuInfo, ok := byCpuId[cpuId]
+		if !ok {
+			continue
+		}
+		sockets = append(sockets, cpuInfo.SocketId)
+		numaNodes = append(numaNodes, cpuInfo.NumaNode)
+	}
+
+	absBitmap := cpuMap.ToAbstractCPUs(cpuSet)
+	splitsSMT := !cpuMap.ToMachineCPUs(absBitmap).IsSubsetOf(cpuSet)
+
+	// Core IDs may repeat within a socket, so take the cores from the CPUMap.
+	cores := []string{}
+	absBitmap.Range(func(absIdx uint32) {
+		cores = append(cores, cpuMap.AbstractToMachine[absIdx].String())
+	})
+
+	return ContainerAssignment{
+		CPUs:         cpuSet.String(),
+		AbstractCPUs: cpuset.New(bitmaputil.List(absBitmap)...).String(),
+		Sockets:      sortedUnique(sockets),
+		NUMANodes:    sortedUnique(numaNodes),
+		Cores:        cores,
+		SplitsSMT:    splitsSMT,
+	}
+}
+
+func memoryNUMANodes(blocks []MemoryBlock) []int {
+	numaNodes := []int{}
+	for _, block := range blocks {
+		numaNodes = append(numaNodes, block.NUMAAffinity...)
+	}
+	if len(numaNodes) == 0 {
+		return nil
+	}
+	return sortedUnique(numaNodes)
+}
+
+func sortedUnique(s []int) []int {
+	slices.Sort(s)
+	return slices.Compact(s)
+}
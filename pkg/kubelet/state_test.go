@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package kubelet
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+)
+
+// testCpuInfos returns a 2 socket machine with 1 NUMA node per socket, 4 cores
+// per socket and 2 threads per core. Core N has machine CPUs N and N+8.
+func testCpuInfos() []cpuinfo.CPUInfo {
+	cpuInfos := []cpuinfo.CPUInfo{}
+	for thread := range 2 {
+		for coreId := range 8 {
+			cpuInfos = append(cpuInfos, cpuinfo.CPUInfo{
+				CpuId:    thread*8 + coreId,
+				SocketId: coreId / 4,
+				CoreId:   coreId % 4,
+				NumaNode: coreId / 4,
+			})
+		}
+	}
+	return cpuInfos
+}
+
+func writeState(t *testing.T, hostRoot, name, data string) {
+	t.Helper()
+	filename := path.Join(hostRoot, "var/lib/kubelet", name)
+	if err := os.MkdirAll(path.Dir(filename), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	hostRoot := t.TempDir()
+	writeState(t, hostRoot, "cpu_manager_state", `{
+		"policyName": "static",
+		"defaultCpuSet": "0,8,3-7,11-15",
+		"entries": {
+			"pod-b": {"app": "1,9"},
+			"pod-a": {"app": "2", "sidecar": "3-4"}
+		},
+		"checksum": 1
+	}`)
+	writeState(t, hostRoot, "memory_manager_state", `{
+		"policyName": "Static",
+		"machineState": {},
+		"entries": {
+			"pod-b": {"app": [{"numaAffinity": [1], "type": "memory", "size": 1073741824}]}
+		},
+		"checksum": 2
+	}`)
+	t.Setenv("HOST_ROOT", hostRoot)
+
+	cpuState, err := ReadCPUManagerState()
+	if err != nil {
+		t.Fatalf("ReadCPUManagerState() error = %v", err)
+	}
+	memState, err := ReadMemoryManagerState()
+	if err != nil {
+		t.Fatalf("ReadMemoryManagerState() error = %v", err)
+	}
+	cpuInfos := testCpuInfos()
+	got, err := NewReport(cpuState, memState, cpuInfos, cpumap.NewCPUMap(cpuInfos))
+	if err != nil {
+		t.Fatalf("NewReport() error = %v", err)
+	}
+
+	want := Report{
+		CPUPolicyName:    "static",
+		MemoryPolicyName: "Static",
+		DefaultCPUSet:    "0,8,3-7,11-15",
+		Containers: []ContainerAssignment{
+			{
+				PodUID:        "pod-a",
+				ContainerName: "app",
+				CPUs:          "2",
+				AbstractCPUs:  "2",
+				Sockets:       []int{0},
+				NUMANodes:     []int{0},
+				Cores:         []string{"2,10"},
+				SplitsSMT:     true,
+			},
+			{
+				PodUID:        "pod-a",
+				ContainerName: "sidecar",
+				CPUs:          "3-4",
+				AbstractCPUs:  "3-4",
+				Sockets:       []int{0, 1},
+				NUMANodes:     []int{0, 1},
+				Cores:         []string{"3,11", "4,12"},
+				SplitsSMT:     true,
+				CrossesNUMA:   true,
+			},
+			{
+				PodUID:          "pod-b",
+				ContainerName:   "app",
+				CPUs:            "1,9",
+				AbstractCPUs:    "1",
+				Sockets:         []int{0},
+				NUMANodes:       []int{0},
+				Cores:           []string{"1,9"},
+				MemoryNUMANodes: []int{1},
+				CrossesNUMA:     true,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewReport() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewReport_repeatedCoreIds(t *testing.T) {
+	// Two dies of one socket, whose cores both have core ID 0.
+	cpuInfos := []cpuinfo.CPUInfo{
+		{CpuId: 0, SocketId: 0, CoreId: 0, ThreadSiblings: "0,2"},
+		{CpuId: 1, SocketId: 0, CoreId: 0, ThreadSiblings: "1,3"},
+		{CpuId: 2, SocketId: 0, CoreId: 0, ThreadSiblings: "0,2"},
+		{CpuId: 3, SocketId: 0, CoreId: 0, ThreadSiblings: "1,3"},
+	}
+	cpuState := &CPUManagerState{
+		PolicyName: "static",
+		Entries: map[string]map[string]string{
+			"pod-a": {"app": "0-1"},
+			"pod-b": {"app": "2,0"},
+		},
+	}
+	got, err := NewReport(cpuState, nil, cpuInfos, cpumap.NewCPUMap(cpuInfos))
+	if err != nil {
+		t.Fatalf("NewReport() error = %v", err)
+	}
+
+	tests := []struct {
+		cores     []string
+		splitsSMT bool
+	}{
+		{cores: []string{"0,2", "1,3"}, splitsSMT: true},
+		{cores: []string{"0,2"}, splitsSMT: false},
+	}
+	for i, tt := range tests {
+		if container := got.Containers[i]; !reflect.DeepEqual(container.Cores, tt.cores) || container.SplitsSMT != tt.splitsSMT {
+			t.Errorf("NewReport() container %s Cores = %v, SplitsSMT = %v, want %v, %v",
+				container.PodUID, container.Cores, container.SplitsSMT, tt.cores, tt.splitsSMT)
+		}
+	}
+}
+
+func TestReadCPUManagerState_invalid(t *testing.T) {
+	hostRoot := t.TempDir()
+	writeState(t, hostRoot, "cpu_manager_state", "not json")
+	t.Setenv("HOST_ROOT", hostRoot)
+
+	if _, err := ReadCPUManagerState(); err == nil {
+		t.Errorf("ReadCPUManagerState() error = nil, want error")
+	}
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+)
+
+// AcceleratorKind distinguishes GPUs from other accelerators.
+type AcceleratorKind string
+
+const (
+	// GPUKind is a display or 3D controller.
+	GPUKind AcceleratorKind = "gpu"
+	// ProcessingAcceleratorKind is a processing accelerator (e.g. an NPU).
+	ProcessingAcceleratorKind AcceleratorKind = "accelerator"
+)
+
+// Accelerator is a GPU or processing accelerator with its device nodes.
+type Accelerator struct {
+	Device PCIEDeviceInfo  `json:"device"`
+	Kind   AcceleratorKind `json:"kind"`
+
+	// Card and Render are the DRM nodes (e.g. "/dev/dri/card0" and
+	// "/dev/dri/renderD128"), Accel is the compute accelerator node (e.g.
+	// "/dev/accel/accel0"). They are empty when the driver did not create
+	// them.
+	Card   string `json:"card,omitempty"`
+	Render string `json:"render,omitempty"`
+	Accel  string `json:"accel,omitempty"`
+
+	// NVIDIAMinor is the minor number of /dev/nvidiaN, nil unless reported by
+	// the NVIDIA driver.
+	NVIDIAMinor *int `json:"nvidiaMinor,omitempty"`
+
+	// LocalCPUs are the machine CPUs local to the device.
+	LocalCPUs string `json:"localCpus"`
+}
+
+// DeviceNodes returns the paths of all the device nodes of the accelerator.
+func (a Accelerator) DeviceNodes() []string {
+	nodes := []string{}
+	if a.NVIDIAMinor != nil {
+		nodes = append(nodes, fmt.Sprintf("/dev/nvidia%d", *a.NVIDIAMinor))
+	}
+	for _, node := range []string{a.Card, a.Render, a.Accel} {
+		if node != "" {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// AcceleratorKindOf returns the kind of accelerator of the PCI class, or
+// false when the class is not an accelerator.
+func AcceleratorKindOf(class string) (AcceleratorKind, bool) {
+	class = strings.TrimPrefix(strings.TrimSpace(class), "0x")
+	switch {
+	case strings.HasPrefix(class, "03"):
+		return GPUKind, true
+	case strings.HasPrefix(class, "12"):
+		return ProcessingAcceleratorKind, true
+	default:
+		return "", false
+	}
+}
+
+// Accelerators returns the GPUs and processing accelerators among the devices,
+// ordered by address. Device nodes are read from the host when called.
+func (p *PCIEInfo) Accelerators() ([]Accelerator, error) {
+	accelerators := []Accelerator{}
+	for _, device := range p.byAddress {
+		accelerator, ok, err := NewAccelerator(device)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			accelerators = append(accelerators, accelerator)
+		}
+	}
+	slices.SortFunc(accelerators, func(a, b Accelerator) int {
+		return strings.Compare(a.Device.Address, b.Device.Address)
+	})
+	return accelerators, nil
+}
+
+// NewAccelerator returns the accelerator of the device, or false when the
+// device is neither a GPU nor a processing accelerator. Device nodes are read
+// from the host when called.
+func NewAccelerator(device PCIEDeviceInfo) (Accelerator, bool, error) {
+	kind, ok := AcceleratorKindOf(device.Class)
+	if !ok {
+		return Accelerator{}, false, nil
+	}
+	localCpus, err := device.LocalCPUs()
+	if err != nil {
+		return Accelerator{}, false, err
+	}
+	devicePath := cpuinfo.HostSys("bus/pci/devices", device.Address)
+	accelerator := Accelerator{
+		Device:    device,
+		Kind:      kind,
+		Card:      findCharDevice(devicePath, "drm/card*", "/dev/dri"),
+		Render:    findCharDevice(devicePath, "drm/renderD*", "/dev/dri"),
+		Accel:     findCharDevice(devicePath, "accel/accel*", "/dev/accel"),
+		LocalCPUs: localCpus.String(),
+	}
+	if minor, ok := NVIDIAMinor(device.Address); ok {
+		accelerator.NVIDIAMinor = &minor
+	}
+	return accelerator, true, nil
+}
+
+// charDeviceClasses maps sysfs class directories under a PCI device to the
+// /dev directory of their character devices.
+var charDeviceClasses = []struct {
+	glob string
+	dir  string
+}{
+	{glob: "nvme/nvme*", dir: "/dev"},
+	{glob: "drm/card*", dir: "/dev/dri"},
+	{glob: "drm/renderD*", dir: "/dev/dri"},
+	{glob: "accel/accel*", dir: "/dev/accel"},
+}
+
+// CharDevices returns the /dev paths of the character devices the driver of
+// the device created: NVMe controllers, DRM cards and render nodes, and
+// compute accelerators.
+func CharDevices(address string) []string {
+	devicePath := cpuinfo.HostSys("bus/pci/devices", address)
+	devices := []string{}
+	for _, class := range charDeviceClasses {
+		devices = append(devices, findCharDevices(devicePath, class.glob, class.dir)...)
+	}
+	return devices
+}
+
+// findCharDevice returns the /dev path of the first character device matching
+// the glob under the device, or "" when there is none.
+func findCharDevice(devicePath, glob, dir string) string {
+	if devices := findCharDevices(devicePath, glob, dir); len(devices) > 0 {
+		return devices[0]
+	}
+	return ""
+}
+
+// findCharDevices returns the /dev paths of the character devices matching
+// the glob under the device, ordered by name.
+func findCharDevices(devicePath, glob, dir string) []string {
+	matches, err := filepath.Glob(filepath.Join(devicePath, glob))
+	if err != nil {
+		return nil
+	}
+	slices.Sort(matches)
+	devices := []string{}
+	for _, match := range matches {
+		// Connectors (e.g. card0-DP-1) have no device number.
+		if _, err := os.Stat(filepath.Join(match, "dev")); err != nil {
+			continue
+		}
+		devices = append(devices, filepath.Join(dir, filepath.Base(match)))
+	}
+	return devices
+}
+
+// NVIDIAMinor reads the device minor number of a GPU from the NVIDIA driver.
+func NVIDIAMinor(address string) (int, bool) {
+	lines, err := cpuinfo.ReadLines(cpuinfo.HostProc("driver/nvidia/gpus", address, "information"))
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range lines {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) < 2 || strings.TrimSpace(fields[0]) != "Device Minor" {
+			continue
+		}
+		minor, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return 0, false
+		}
+		return minor, true
+	}
+	return 0, false
+}
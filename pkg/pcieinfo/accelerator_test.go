@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pravk03/topologyutil/pkg/internal/sysfstest"
+)
+
+func TestAccelerators(t *testing.T) {
+	npu := testDevice{
+		path: "pci0000:80/0000:80:02.0",
+		files: map[string]string{
+			"vendor":                "0x8086\n",
+			"device":                "0x7d1d\n",
+			"class":                 "0x120000\n",
+			"numa_node":             "1\n",
+			"local_cpus":            "00000000,ffff0000\n",
+			"accel/accel0/dev":      "261:0\n",
+			"drm/card1/dev":         "226:1\n",
+			"drm/card1-DP-1/status": "disconnected\n",
+		},
+		links: map[string]string{"driver": "bus/pci/drivers/intel_vpu"},
+	}
+	hostRoot := newTestHostRoot(t, []testDevice{
+		gpu("pci0000:00/0000:00:01.0", "0"),
+		gpu("pci0000:80/0000:80:01.0", "1"),
+		npu,
+		nic("pci0000:80/0000:80:03.0", nil, nil),
+	})
+	sysfstest.WriteFile(t, hostRoot, "sys/devices/pci0000:00/0000:00:01.0/drm/card0/dev", "226:0\n")
+	sysfstest.WriteFile(t, hostRoot, "sys/devices/pci0000:00/0000:00:01.0/drm/renderD128/dev", "226:128\n")
+	sysfstest.WriteFile(t, hostRoot, "proc/driver/nvidia/gpus/0000:80:01.0/information",
+		"Model: \t\t NVIDIA A100-SXM4-40GB\nIRQ:   \t\t 42\nDevice Minor: \t 3\n")
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+	accelerators, err := pcieInfo.Accelerators()
+	if err != nil {
+		t.Fatalf("Accelerators() error = %v", err)
+	}
+
+	minor := 3
+	tests := []struct {
+		address     string
+		kind        AcceleratorKind
+		card        string
+		render      string
+		accel       string
+		nvidiaMinor *int
+		localCpus   string
+		deviceNodes []string
+	}{
+		{
+			address:     "0000:00:01.0",
+			kind:        GPUKind,
+			card:        "/dev/dri/card0",
+			render:      "/dev/dri/renderD128",
+			localCpus:   "0-15",
+			deviceNodes: []string{"/dev/dri/card0", "/dev/dri/renderD128"},
+		},
+		{
+			address:     "0000:80:01.0",
+			kind:        GPUKind,
+			nvidiaMinor: &minor,
+			localCpus:   "0-15",
+			deviceNodes: []string{"/dev/nvidia3"},
+		},
+		{
+			address:     "0000:80:02.0",
+			kind:        ProcessingAcceleratorKind,
+			card:        "/dev/dri/card1",
+			accel:       "/dev/accel/accel0",
+			localCpus:   "16-31",
+			deviceNodes: []string{"/dev/dri/card1", "/dev/accel/accel0"},
+		},
+	}
+	if len(accelerators) != len(tests) {
+		t.Fatalf("Accelerators() = %d accelerators, want %d", len(accelerators), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got := accelerators[i]
+			if got.Device.Address != tt.address || got.Kind != tt.kind || got.Card != tt.card ||
+				got.Render != tt.render || got.Accel != tt.accel || got.LocalCPUs != tt.localCpus {
+				t.Errorf("Accelerators()[%d] = %+v, want %+v", i, got, tt)
+			}
+			if !reflect.DeepEqual(got.NVIDIAMinor, tt.nvidiaMinor) {
+				t.Errorf("Accelerators()[%d].NVIDIAMinor = %v, want %v", i, got.NVIDIAMinor, tt.nvidiaMinor)
+			}
+			if nodes := got.DeviceNodes(); !reflect.DeepEqual(nodes, tt.deviceNodes) {
+				t.Errorf("DeviceNodes() = %v, want %v", nodes, tt.deviceNodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Link is the kind of path between two devices, as reported by
+// `nvidia-smi topo -m`, from closest to farthest.
+type Link string
+
+const (
+	// LinkSelf is a device to itself.
+	LinkSelf Link = "X"
+	// LinkPIX traverses at most a single PCIe switch.
+	LinkPIX Link = "PIX"
+	// LinkPXB traverses multiple PCIe switches without the host bridge.
+	LinkPXB Link = "PXB"
+	// LinkPHB traverses the PCIe host bridge of a root complex.
+	LinkPHB Link = "PHB"
+	// LinkNODE traverses several host bridges within a NUMA node.
+	LinkNODE Link = "NODE"
+	// LinkSYS traverses the interconnect between NUMA nodes.
+	LinkSYS Link = "SYS"
+)
+
+// Link returns the kind of path between the devices.
+func (t *Topology) Link(a, b string) (Link, error) {
+	nodeA, ok := t.nodes[a]
+	if !ok {
+		return "", fmt.Errorf("device %s not found", a)
+	}
+	nodeB, ok := t.nodes[b]
+	if !ok {
+		return "", fmt.Errorf("device %s not found", b)
+	}
+	if a == b {
+		return LinkSelf, nil
+	}
+
+	common, ok := t.CommonAncestor(a, b)
+	if !ok {
+		numaA, numaB := nodeA.Device.NUMANode, nodeB.Device.NUMANode
+		if numaA >= 0 && numaA == numaB {
+			return LinkNODE, nil
+		}
+		return LinkSYS, nil
+	}
+	if common.Type == RootComplex {
+		return LinkPHB, nil
+	}
+
+	// Count the switches the path goes through, including the common one.
+	switches := 0
+	for _, node := range []*TopologyNode{nodeA.Parent, nodeB.Parent} {
+		for ; node != common; node = node.Parent {
+			if node.Type == SwitchUpstreamPort {
+				switches++
+			}
+		}
+	}
+	if common.Type == SwitchUpstreamPort {
+		switches++
+	}
+	if switches <= 1 {
+		return LinkPIX, nil
+	}
+	return LinkPXB, nil
+}
+
+// AffinityMatrix holds the links between a set of devices and their CPU and
+// NUMA affinity.
+type AffinityMatrix struct {
+	Devices      []PCIEDeviceInfo `json:"devices"`
+	Links        [][]Link         `json:"links"`
+	CPUAffinity  []string         `json:"cpuAffinity"`
+	NUMAAffinity []int            `json:"numaAffinity"`
+}
+
+// AffinityMatrix computes the links between every pair of devices.
+func (t *Topology) AffinityMatrix(addresses []string) (AffinityMatrix, error) {
+	m := AffinityMatrix{
+		Devices:      make([]PCIEDeviceInfo, len(addresses)),
+		Links:        make([][]Link, len(addresses)),
+		CPUAffinity:  make([]string, len(addresses)),
+		NUMAAffinity: make([]int, len(addresses)),
+	}
+	for i, a := range addresses {
+		node, ok := t.nodes[a]
+		if !ok {
+			return AffinityMatrix{}, fmt.Errorf("device %s not found", a)
+		}
+		m.Devices[i] = *node.Device
+		m.NUMAAffinity[i] = node.Device.NUMANode
+		localCpus, err := node.Device.LocalCPUs()
+		if err != nil {
+			return AffinityMatrix{}, err
+		}
+		m.CPUAffinity[i] = localCpus.String()
+
+		m.Links[i] = make([]Link, len(addresses))
+		for j, b := range addresses {
+			if m.Links[i][j], err = t.Link(a, b); err != nil {
+				return AffinityMatrix{}, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// Label returns a short name of the device for the matrix, such as GPU0 or
+// NIC1, numbering each kind of device in order.
+func (m AffinityMatrix) Label(idx int) string {
+	kind := deviceKind(m.Devices[idx].Class)
+	n := 0
+	for _, device := range m.Devices[:idx] {
+		if deviceKind(device.Class) == kind {
+			n++
+		}
+	}
+	return kind + strconv.Itoa(n)
+}
+
+func deviceKind(class string) string {
+	class = strings.TrimPrefix(class, "0x")
+	switch {
+	case strings.HasPrefix(class, "03"):
+		return "GPU"
+	case strings.HasPrefix(class, "02"):
+		return "NIC"
+	case strings.HasPrefix(class, "0108"):
+		return "NVME"
+	default:
+		return "DEV"
+	}
+}
+
+// String renders the matrix like `nvidia-smi topo -m`, followed by a legend.
+func (m AffinityMatrix) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	header := []string{""}
+	for i := range m.Devices {
+		header = append(header, m.Label(i))
+	}
+	header = append(header, "CPU Affinity", "NUMA Affinity")
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for i := range m.Devices {
+		row := []string{m.Label(i)}
+		for _, link := range m.Links[i] {
+			row = append(row, string(link))
+		}
+		numaNode := "N/A"
+		if m.NUMAAffinity[i] >= 0 {
+			numaNode = strconv.Itoa(m.NUMAAffinity[i])
+		}
+		cpus := m.CPUAffinity[i]
+		if cpus == "" {
+			cpus = "N/A"
+		}
+		row = append(row, cpus, numaNode)
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+
+	b.WriteString("\nDevices:\n\n")
+	for i, device := range m.Devices {
+		fmt.Fprintf(&b, "  %s: %s %s:%s %s\n", m.Label(i), device.Address, device.VendorID, device.DeviceID, device.Driver)
+	}
+	b.WriteString(`
+Legend:
+
+  X    = Self
+  SYS  = Connection traversing PCIe as well as the SMP interconnect between NUMA nodes
+  NODE = Connection traversing PCIe as well as the interconnect between PCIe Host Bridges within a NUMA node
+  PHB  = Connection traversing PCIe as well as a PCIe Host Bridge
+  PXB  = Connection traversing multiple PCIe switches (without traversing the PCIe Host Bridge)
+  PIX  = Connection traversing at most a single PCIe switch
+`)
+	return b.String()
+}
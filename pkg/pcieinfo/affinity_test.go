@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopology_AffinityMatrix(t *testing.T) {
+	devices := append(testDevices(),
+		// A second switch nested below the first one.
+		bridge("pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:02.0"),
+		bridge("pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:02.0/0000:05:00.0"),
+		bridge("pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:02.0/0000:05:00.0/0000:06:00.0"),
+		gpu("pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:02.0/0000:05:00.0/0000:06:00.0/0000:07:00.0", "0"),
+		// Another root complex on the same NUMA node.
+		bridge("pci0000:40/0000:40:01.0"),
+		gpu("pci0000:40/0000:40:01.0/0000:41:00.0", "0"),
+		// A device on the root complex of the first switch.
+		gpu("pci0000:00/0000:00:02.0", "0"),
+	)
+	newTestHostRoot(t, devices)
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+	topology := pcieInfo.NewTopology()
+
+	addresses := []string{"0000:03:00.0", "0000:04:00.0", "0000:07:00.0", "0000:00:02.0", "0000:41:00.0", "0000:81:00.0"}
+	got, err := topology.AffinityMatrix(addresses)
+	if err != nil {
+		t.Fatalf("Topology.AffinityMatrix() error = %v", err)
+	}
+	wantLinks := [][]Link{
+		{LinkSelf, LinkPIX, LinkPXB, LinkPHB, LinkNODE, LinkSYS},
+		{LinkPIX, LinkSelf, LinkPXB, LinkPHB, LinkNODE, LinkSYS},
+		{LinkPXB, LinkPXB, LinkSelf, LinkPHB, LinkNODE, LinkSYS},
+		{LinkPHB, LinkPHB, LinkPHB, LinkSelf, LinkNODE, LinkSYS},
+		{LinkNODE, LinkNODE, LinkNODE, LinkNODE, LinkSelf, LinkSYS},
+		{LinkSYS, LinkSYS, LinkSYS, LinkSYS, LinkSYS, LinkSelf},
+	}
+	if !reflect.DeepEqual(got.Links, wantLinks) {
+		t.Errorf("Topology.AffinityMatrix() Links = %v, want %v", got.Links, wantLinks)
+	}
+	wantNUMA := []int{0, 0, 0, 0, 0, 1}
+	if !reflect.DeepEqual(got.NUMAAffinity, wantNUMA) {
+		t.Errorf("Topology.AffinityMatrix() NUMAAffinity = %v, want %v", got.NUMAAffinity, wantNUMA)
+	}
+	if got.CPUAffinity[0] != "0-15" {
+		t.Errorf("Topology.AffinityMatrix() CPUAffinity[0] = %v, want 0-15", got.CPUAffinity[0])
+	}
+	if label := got.Label(5); label != "GPU5" {
+		t.Errorf("AffinityMatrix.Label() = %v, want GPU5", label)
+	}
+
+	if _, err := topology.AffinityMatrix([]string{"0000:ff:00.0"}); err == nil {
+		t.Errorf("Topology.AffinityMatrix() error = nil, want error")
+	}
+}
@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Resource flags of the sysfs `resource` file, see include/linux/ioport.h.
+const (
+	resourceIO       = 0x00000100
+	resourceMem      = 0x00000200
+	resourcePrefetch = 0x00002000
+	resourceMem64    = 0x00100000
+	resourceDisabled = 0x10000000
+)
+
+// Indices of the resources after the six standard BARs.
+const (
+	romResource    = 6
+	iovResources   = 7
+	numIOVBARs     = 6
+	bridgeResource = iovResources + numIOVBARs
+)
+
+// extCapIDReBAR is the ID of the Resizable BAR extended capability.
+const extCapIDReBAR = 0x0015
+
+// LargeBARSize is the size above which a BAR is considered large. Larger
+// BARs need above 4G decoding and let peers map all of a GPU's memory.
+const LargeBARSize = 256 << 20
+
+// BARType is the address space of a BAR.
+type BARType string
+
+const (
+	MemoryBAR BARType = "mem"
+	IOBAR     BARType = "io"
+)
+
+// BAR is a base address register region of a device.
+type BAR struct {
+	// Index is the line of the region in the `resource` file.
+	Index int `json:"index"`
+	// Name is "BAR0" to "BAR5", "ROM", "VF BAR0" to "VF BAR5" for SR-IOV
+	// virtual function BARs, or "window0" and up for bridge windows.
+	Name    string  `json:"name"`
+	Type    BARType `json:"type"`
+	Address uint64  `json:"address"`
+	Size    uint64  `json:"size"`
+
+	Prefetchable bool `json:"prefetchable,omitempty"`
+	Is64Bit      bool `json:"is64Bit,omitempty"`
+
+	// ResizableSizes are the sizes the BAR can be resized to, from the
+	// `resourceN_resize` file. It is nil for BARs that are not resizable or
+	// when the kernel does not expose it.
+	ResizableSizes []uint64 `json:"resizableSizes,omitempty"`
+}
+
+// Resizable returns true when the BAR supports the Resizable BAR capability.
+func (b BAR) Resizable() bool {
+	return len(b.ResizableSizes) > 0
+}
+
+// MaxResizableSize returns the largest size the BAR can be resized to.
+func (b BAR) MaxResizableSize() uint64 {
+	if len(b.ResizableSizes) == 0 {
+		return 0
+	}
+	return b.ResizableSizes[len(b.ResizableSizes)-1]
+}
+
+func barName(index int) string {
+	switch {
+	case index < romResource:
+		return "BAR" + strconv.Itoa(index)
+	case index == romResource:
+		return "ROM"
+	case index < bridgeResource:
+		return "VF BAR" + strconv.Itoa(index-iovResources)
+	default:
+		return "window" + strconv.Itoa(index-bridgeResource)
+	}
+}
+
+// readBARs parses the `resource` file of the device, skipping unassigned
+// and disabled regions.
+func readBARs(devicePath string) []BAR {
+	data, err := readFile(filepath.Join(devicePath, "resource"))
+	if err != nil {
+		return nil
+	}
+	var rebarSizes map[int][]uint64
+	bars := []BAR{}
+	for index, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		bar, ok := parseResource(index, line)
+		if !ok {
+			continue
+		}
+		if index < romResource {
+			bar.ResizableSizes = readResizableSizes(filepath.Join(devicePath, fmt.Sprintf("resource%d_resize", index)))
+			if bar.ResizableSizes == nil {
+				// Older kernels do not expose resourceN_resize, fall back to
+				// the capability, which is only readable as root.
+				if rebarSizes == nil {
+					rebarSizes = readReBARCapability(devicePath)
+				}
+				bar.ResizableSizes = rebarSizes[index]
+			}
+		}
+		bars = append(bars, bar)
+	}
+	return bars
+}
+
+// parseResource parses a "start end flags" line of the `resource` file.
+func parseResource(index int, line string) (BAR, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return BAR{}, false
+	}
+	values := make([]uint64, 3)
+	for i, field := range fields {
+		value, err := strconv.ParseUint(strings.TrimPrefix(field, "0x"), 16, 64)
+		if err != nil {
+			return BAR{}, false
+		}
+		values[i] = value
+	}
+	start, end, flags := values[0], values[1], values[2]
+	if end == 0 || end < start || flags&resourceDisabled != 0 {
+		return BAR{}, false
+	}
+
+	bar := BAR{
+		Index:        index,
+		Name:         barName(index),
+		Address:      start,
+		Size:         end - start + 1,
+		Prefetchable: flags&resourcePrefetch != 0,
+		Is64Bit:      flags&resourceMem64 != 0,
+	}
+	switch {
+	case flags&resourceMem != 0:
+		bar.Type = MemoryBAR
+	case flags&resourceIO != 0:
+		bar.Type = IOBAR
+	default:
+		return BAR{}, false
+	}
+	return bar, true
+}
+
+// readResizableSizes reads the supported sizes of a resizable BAR. The file
+// holds a hex bitmap where bit n means a size of 2^n MiB.
+func readResizableSizes(filename string) []uint64 {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil
+	}
+	bitmap, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(data), "0x"), 16, 64)
+	if err != nil {
+		return nil
+	}
+	sizes := []uint64{}
+	for bit := 0; bit < 44; bit++ {
+		if bitmap&(1<<bit) != 0 {
+			sizes = append(sizes, uint64(1)<<(20+bit))
+		}
+	}
+	if len(sizes) == 0 {
+		return nil
+	}
+	return sizes
+}
+
+// readReBARCapability reads the sizes supported by each resizable BAR from
+// the Resizable BAR extended capability in the config space.
+func readReBARCapability(devicePath string) map[int][]uint64 {
+	rebarSizes := map[int][]uint64{}
+	config, err := os.ReadFile(filepath.Join(devicePath, "config"))
+	if err != nil {
+		return rebarSizes
+	}
+	offset, ok := findExtCapability(config, extCapIDReBAR)
+	if !ok || offset+12 > len(config) {
+		return rebarSizes
+	}
+	// Each BAR has a capability register, where bit n+4 means a size of
+	// 2^n MiB, followed by a control register holding the BAR index. The
+	// first control register also holds the number of resizable BARs.
+	count := int(binary.LittleEndian.Uint32(config[offset+8:])>>5) & 0x7
+	for i := 0; i < count && offset+12+8*i <= len(config); i++ {
+		capability := binary.LittleEndian.Uint32(config[offset+4+8*i:])
+		control := binary.LittleEndian.Uint32(config[offset+8+8*i:])
+		sizes := []uint64{}
+		for bit := 4; bit < 32; bit++ {
+			if capability&(1<<bit) != 0 {
+				sizes = append(sizes, uint64(1)<<(20+bit-4))
+			}
+		}
+		if len(sizes) > 0 {
+			rebarSizes[int(control&0x7)] = sizes
+		}
+	}
+	return rebarSizes
+}
+
+// LargeBAR returns true when the device has a memory BAR larger than
+// LargeBARSize, such as a GPU exposing all its memory through BAR1.
+func (d PCIEDeviceInfo) LargeBAR() bool {
+	for _, bar := range d.BARs {
+		if bar.Index < romResource && bar.Type == MemoryBAR && bar.Size > LargeBARSize {
+			return true
+		}
+	}
+	return false
+}
+
+// ResizableBAR returns true when any BAR of the device is resizable.
+func (d PCIEDeviceInfo) ResizableBAR() bool {
+	for _, bar := range d.BARs {
+		if bar.Resizable() {
+			return true
+		}
+	}
+	return false
+}
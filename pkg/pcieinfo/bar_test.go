@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const gpuResource = `0x00000000fb000000 0x00000000fbffffff 0x0000000000040200
+0x0000038000000000 0x0000039fffffffff 0x000000000014220c
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x0000039ff0000000 0x0000039ff1ffffff 0x000000000014220c
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x000000000000e000 0x000000000000e07f 0x0000000000040101
+0x00000000fc000000 0x00000000fc07ffff 0x0000000000046200
+`
+
+func TestReadBARs(t *testing.T) {
+	device := gpu("pci0000:00/0000:00:01.0/0000:01:00.0", "0")
+	device.files["resource"] = gpuResource
+	device.files["resource1_resize"] = "0x0000000000003fc0\n"
+	newTestHostRoot(t, []testDevice{bridge("pci0000:00/0000:00:01.0"), device})
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+	got, ok := pcieInfo.FindDeviceByAddress("0000:01:00.0")
+	if !ok {
+		t.Fatalf("FindDeviceByAddress() found = false, want true")
+	}
+	want := []BAR{
+		{Index: 0, Name: "BAR0", Type: MemoryBAR, Address: 0xfb000000, Size: 16 << 20},
+		{
+			Index: 1, Name: "BAR1", Type: MemoryBAR, Address: 0x38000000000, Size: 128 << 30,
+			Prefetchable: true, Is64Bit: true,
+			ResizableSizes: []uint64{64 << 20, 128 << 20, 256 << 20, 512 << 20, 1 << 30, 2 << 30, 4 << 30, 8 << 30},
+		},
+		{Index: 3, Name: "BAR3", Type: MemoryBAR, Address: 0x39ff0000000, Size: 32 << 20, Prefetchable: true, Is64Bit: true},
+		{Index: 5, Name: "BAR5", Type: IOBAR, Address: 0xe000, Size: 128},
+		{Index: 6, Name: "ROM", Type: MemoryBAR, Address: 0xfc000000, Size: 512 << 10, Prefetchable: true},
+	}
+	if !reflect.DeepEqual(got.BARs, want) {
+		t.Errorf("BARs = %+v, want %+v", got.BARs, want)
+	}
+	if !got.LargeBAR() {
+		t.Errorf("LargeBAR() = false, want true")
+	}
+	if !got.ResizableBAR() {
+		t.Errorf("ResizableBAR() = false, want true")
+	}
+	if got, want := got.BARs[1].MaxResizableSize(), uint64(8<<30); got != want {
+		t.Errorf("MaxResizableSize() = %d, want %d", got, want)
+	}
+}
+
+func TestReadBARsReBARCapability(t *testing.T) {
+	// Resizable BAR capability at 0x100 with a single BAR: BAR1 supports
+	// 256MiB to 16GiB and is currently 256MiB.
+	config := make([]byte, 4096)
+	binary.LittleEndian.PutUint32(config[0x100:], 0x00010000|extCapIDReBAR)
+	binary.LittleEndian.PutUint32(config[0x104:], 0x000ff000)
+	binary.LittleEndian.PutUint32(config[0x108:], 8<<8|1<<5|1)
+
+	device := gpu("pci0000:00/0000:00:01.0", "0")
+	device.files["resource"] = "0x0000000000000000 0x0000000000000000 0x0000000000000000\n" +
+		"0x00000000e0000000 0x00000000efffffff 0x000000000014220c\n"
+	device.files["config"] = string(config)
+	newTestHostRoot(t, []testDevice{device})
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+	got, ok := pcieInfo.FindDeviceByAddress("0000:00:01.0")
+	if !ok {
+		t.Fatalf("FindDeviceByAddress() found = false, want true")
+	}
+	want := []BAR{{
+		Index: 1, Name: "BAR1", Type: MemoryBAR, Address: 0xe0000000, Size: 256 << 20,
+		Prefetchable: true, Is64Bit: true,
+		ResizableSizes: []uint64{256 << 20, 512 << 20, 1 << 30, 2 << 30, 4 << 30, 8 << 30, 16 << 30, 32 << 30},
+	}}
+	if !reflect.DeepEqual(got.BARs, want) {
+		t.Errorf("BARs = %+v, want %+v", got.BARs, want)
+	}
+	if got.LargeBAR() {
+		t.Errorf("LargeBAR() = true, want false")
+	}
+}
+
+func TestReadReBARCapability_truncated(t *testing.T) {
+	// The capability claims two resizable BARs, but the config space ends
+	// before the second one.
+	config := make([]byte, 0x114)
+	binary.LittleEndian.PutUint32(config[0x100:], 0x00010000|extCapIDReBAR)
+	binary.LittleEndian.PutUint32(config[0x104:], 0x000ff000)
+	binary.LittleEndian.PutUint32(config[0x108:], 8<<8|2<<5|1)
+
+	tests := []struct {
+		name   string
+		length int
+		want   map[int][]uint64
+	}{
+		{name: "header only", length: 0x108, want: map[int][]uint64{}},
+		{name: "partial control register", length: 0x10a, want: map[int][]uint64{}},
+		{
+			name:   "partial second BAR",
+			length: 0x114,
+			want: map[int][]uint64{
+				1: {256 << 20, 512 << 20, 1 << 30, 2 << 30, 4 << 30, 8 << 30, 16 << 30, 32 << 30},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devicePath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(devicePath, "config"), config[:tt.length], 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if got := readReBARCapability(devicePath); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readReBARCapability() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBARName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{index: 0, want: "BAR0"},
+		{index: 5, want: "BAR5"},
+		{index: 6, want: "ROM"},
+		{index: 7, want: "VF BAR0"},
+		{index: 12, want: "VF BAR5"},
+		{index: 13, want: "window0"},
+	}
+	for _, tt := range tests {
+		if got := barName(tt.index); got != tt.want {
+			t.Errorf("barName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Filter selects devices.
+type Filter func(device PCIEDeviceInfo) bool
+
+// ByClass selects devices whose class code starts with the prefix (e.g.
+// "02" for network controllers, "0302" for 3D controllers).
+func ByClass(prefix string) Filter {
+	prefix = normalizeHex(prefix)
+	return func(device PCIEDeviceInfo) bool {
+		return strings.HasPrefix(normalizeHex(device.Class), prefix)
+	}
+}
+
+// ByVendor selects devices of the vendor ID (e.g. "10de").
+func ByVendor(vendorID string) Filter {
+	vendorID = normalizeHex(vendorID)
+	return func(device PCIEDeviceInfo) bool {
+		return normalizeHex(device.VendorID) == vendorID
+	}
+}
+
+// ByDeviceID selects devices with the device ID (e.g. "20b0").
+func ByDeviceID(deviceID string) Filter {
+	deviceID = normalizeHex(deviceID)
+	return func(device PCIEDeviceInfo) bool {
+		return normalizeHex(device.DeviceID) == deviceID
+	}
+}
+
+// ByDriver selects devices bound to the driver. An empty driver selects
+// unbound devices.
+func ByDriver(driver string) Filter {
+	return func(device PCIEDeviceInfo) bool {
+		return device.Driver == driver
+	}
+}
+
+// ByNUMANode selects devices on the NUMA node.
+func ByNUMANode(numaNode int) Filter {
+	return func(device PCIEDeviceInfo) bool {
+		return device.NUMANode == numaNode
+	}
+}
+
+// ByRootComplex selects devices below the root complex (e.g. "pci0000:00").
+func ByRootComplex(id string) Filter {
+	return func(device PCIEDeviceInfo) bool {
+		return device.PCIERootComplexID == id
+	}
+}
+
+// ByAddress selects devices whose address matches the glob (e.g.
+// "0000:3b:*"). See path.Match for the pattern syntax.
+func ByAddress(glob string) Filter {
+	return func(device PCIEDeviceInfo) bool {
+		matched, err := path.Match(glob, device.Address)
+		return err == nil && matched
+	}
+}
+
+// And selects devices matching all the filters.
+func And(filters ...Filter) Filter {
+	return func(device PCIEDeviceInfo) bool {
+		for _, filter := range filters {
+			if !filter(device) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or selects devices matching any of the filters.
+func Or(filters ...Filter) Filter {
+	return func(device PCIEDeviceInfo) bool {
+		for _, filter := range filters {
+			if filter(device) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not selects devices not matching the filter.
+func Not(filter Filter) Filter {
+	return func(device PCIEDeviceInfo) bool {
+		return !filter(device)
+	}
+}
+
+// Query returns the devices matching all the filters, ordered by address.
+func (p *PCIEInfo) Query(filters ...Filter) []PCIEDeviceInfo {
+	filter := And(filters...)
+	devices := []PCIEDeviceInfo{}
+	for _, device := range p.GetAllDevices() {
+		if filter(device) {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
+
+func normalizeHex(id string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(id), "0x"))
+}
+
+// filterKeys maps the keys of a filter expression to their filters.
+var filterKeys = map[string]func(value string) (Filter, error){
+	"class":  func(value string) (Filter, error) { return ByClass(value), nil },
+	"vendor": func(value string) (Filter, error) { return ByVendor(value), nil },
+	"device": func(value string) (Filter, error) { return ByDeviceID(value), nil },
+	"driver": func(value string) (Filter, error) { return ByDriver(value), nil },
+	"root":   func(value string) (Filter, error) { return ByRootComplex(value), nil },
+	"numa": func(value string) (Filter, error) {
+		numaNode, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NUMA node %q", value)
+		}
+		return ByNUMANode(numaNode), nil
+	},
+	"address": func(value string) (Filter, error) {
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("invalid address pattern %q: %w", value, err)
+		}
+		return ByAddress(value), nil
+	},
+}
+
+// ParseFilter parses a filter expression: comma separated terms that must all
+// match, each `key=value` or `key!=value`, where a value may list
+// alternatives separated by `|`. Keys are class, vendor, device, driver,
+// numa, root and address. For example:
+//
+//	class=0300|0302,vendor=10de,numa=0
+//	class=02,driver!=vfio-pci
+//	address=0000:3b:*
+func ParseFilter(expr string) (Filter, error) {
+	filters := []Filter{}
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(term, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid filter term %q: want key=value", term)
+		}
+		key, negate := strings.CutSuffix(strings.TrimSpace(key), "!")
+		newFilter, ok := filterKeys[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter key %q", key)
+		}
+
+		alternatives := []Filter{}
+		for _, alternative := range strings.Split(value, "|") {
+			filter, err := newFilter(strings.TrimSpace(alternative))
+			if err != nil {
+				return nil, err
+			}
+			alternatives = append(alternatives, filter)
+		}
+		filter := Or(alternatives...)
+		if negate {
+			filter = Not(filter)
+		}
+		filters = append(filters, filter)
+	}
+	return And(filters...), nil
+}
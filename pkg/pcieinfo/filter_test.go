@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testFilterInfo() *PCIEInfo {
+	return NewPCIEInfoFromDevices([]PCIEDeviceInfo{
+		{Address: "0000:81:00.0", VendorID: "10de", DeviceID: "20b0", Class: "0x030200", Driver: "nvidia", PCIERootComplexID: "pci0000:80", NUMANode: 1},
+		{Address: "0000:3b:00.0", VendorID: "10de", DeviceID: "20b0", Class: "0x030200", Driver: "nvidia", PCIERootComplexID: "pci0000:3a", NUMANode: 0},
+		{Address: "0000:3b:00.1", VendorID: "15b3", DeviceID: "101d", Class: "0x020000", Driver: "mlx5_core", PCIERootComplexID: "pci0000:3a", NUMANode: 0},
+		{Address: "0000:3b:00.2", VendorID: "15b3", DeviceID: "101e", Class: "0x020000", Driver: "vfio-pci", PCIERootComplexID: "pci0000:3a", NUMANode: 0},
+		{Address: "0000:00:1f.0", VendorID: "8086", DeviceID: "a1c1", Class: "0x060100", PCIERootComplexID: "pci0000:00", NUMANode: 0},
+	})
+}
+
+func addresses(devices []PCIEDeviceInfo) []string {
+	got := []string{}
+	for _, device := range devices {
+		got = append(got, device.Address)
+	}
+	return got
+}
+
+func TestQuery(t *testing.T) {
+	pcieInfo := testFilterInfo()
+
+	tests := []struct {
+		name    string
+		filters []Filter
+		want    []string
+	}{
+		{
+			name: "all",
+			want: []string{"0000:00:1f.0", "0000:3b:00.0", "0000:3b:00.1", "0000:3b:00.2", "0000:81:00.0"},
+		},
+		{
+			name:    "class",
+			filters: []Filter{ByClass("0x0302")},
+			want:    []string{"0000:3b:00.0", "0000:81:00.0"},
+		},
+		{
+			name:    "vendor and NUMA node",
+			filters: []Filter{ByVendor("0x10DE"), ByNUMANode(0)},
+			want:    []string{"0000:3b:00.0"},
+		},
+		{
+			name:    "device",
+			filters: []Filter{ByDeviceID("101e")},
+			want:    []string{"0000:3b:00.2"},
+		},
+		{
+			name:    "unbound",
+			filters: []Filter{ByDriver("")},
+			want:    []string{"0000:00:1f.0"},
+		},
+		{
+			name:    "root complex",
+			filters: []Filter{ByRootComplex("pci0000:3a"), Not(ByDriver("vfio-pci"))},
+			want:    []string{"0000:3b:00.0", "0000:3b:00.1"},
+		},
+		{
+			name:    "address glob",
+			filters: []Filter{ByAddress("0000:3b:00.[12]")},
+			want:    []string{"0000:3b:00.1", "0000:3b:00.2"},
+		},
+		{
+			name:    "or",
+			filters: []Filter{Or(ByClass("02"), ByClass("06"))},
+			want:    []string{"0000:00:1f.0", "0000:3b:00.1", "0000:3b:00.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addresses(pcieInfo.Query(tt.filters...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	pcieInfo := testFilterInfo()
+
+	tests := []struct {
+		expr    string
+		want    []string
+		wantErr bool
+	}{
+		{expr: "", want: []string{"0000:00:1f.0", "0000:3b:00.0", "0000:3b:00.1", "0000:3b:00.2", "0000:81:00.0"}},
+		{expr: "class=0300|0302,vendor=10de,numa=1", want: []string{"0000:81:00.0"}},
+		{expr: "class=02, driver!=vfio-pci", want: []string{"0000:3b:00.1"}},
+		{expr: "address=0000:3b:*,root=pci0000:3a,device!=20b0", want: []string{"0000:3b:00.1", "0000:3b:00.2"}},
+		{expr: "driver=", want: []string{"0000:00:1f.0"}},
+		{expr: "class", wantErr: true},
+		{expr: "color=red", wantErr: true},
+		{expr: "numa=zero", wantErr: true},
+		{expr: "address=[", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := ParseFilter(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := addresses(pcieInfo.Query(filter)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query(ParseFilter()) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Health holds the power state and Advanced Error Reporting (AER) counters
+// of a device.
+type Health struct {
+	// Enabled is false for devices not enabled by any driver.
+	Enabled bool `json:"enabled"`
+
+	// DriverBound is true when a driver is bound to the device.
+	DriverBound bool `json:"driverBound"`
+
+	// PowerState is the PCI power state (e.g. "D0", "D3hot", "D3cold").
+	PowerState string `json:"powerState,omitempty"`
+
+	// RuntimeStatus is the runtime power management status (e.g. "active",
+	// "suspended"). Idle devices are suspended by their driver.
+	RuntimeStatus string `json:"runtimeStatus,omitempty"`
+
+	// Correctable, NonFatal and Fatal hold the AER error counters by name
+	// (e.g. "BadTLP"). They are nil when the device does not report AER.
+	Correctable map[string]uint64 `json:"correctable,omitempty"`
+	NonFatal    map[string]uint64 `json:"nonFatal,omitempty"`
+	Fatal       map[string]uint64 `json:"fatal,omitempty"`
+
+	CorrectableTotal uint64 `json:"correctableTotal"`
+	NonFatalTotal    uint64 `json:"nonFatalTotal"`
+	FatalTotal       uint64 `json:"fatalTotal"`
+}
+
+// readHealth reads the health attributes of the device, returning nil when
+// the device exposes none of them.
+func readHealth(devicePath string) *Health {
+	enable, enableErr := readIntFromFile(filepath.Join(devicePath, "enable"))
+	powerState, powerErr := readFile(filepath.Join(devicePath, "power_state"))
+	if enableErr != nil && powerErr != nil {
+		return nil
+	}
+	runtimeStatus, _ := readFile(filepath.Join(devicePath, "power/runtime_status"))
+	_, driverErr := os.Lstat(filepath.Join(devicePath, "driver"))
+	health := &Health{
+		Enabled:       enable > 0,
+		DriverBound:   driverErr == nil,
+		PowerState:    strings.TrimSpace(powerState),
+		RuntimeStatus: strings.TrimSpace(runtimeStatus),
+	}
+	health.Correctable, health.CorrectableTotal = readAERCounters(filepath.Join(devicePath, "aer_dev_correctable"), "TOTAL_ERR_COR")
+	health.NonFatal, health.NonFatalTotal = readAERCounters(filepath.Join(devicePath, "aer_dev_nonfatal"), "TOTAL_ERR_NONFATAL")
+	health.Fatal, health.FatalTotal = readAERCounters(filepath.Join(devicePath, "aer_dev_fatal"), "TOTAL_ERR_FATAL")
+	return health
+}
+
+// readAERCounters reads an AER statistics file of "name count" lines. The
+// total is taken from the total line, or summed when it is missing.
+func readAERCounters(filename string, totalName string) (map[string]uint64, uint64) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, 0
+	}
+	counters := make(map[string]uint64)
+	var sum uint64
+	total, hasTotal := uint64(0), false
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		count, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if fields[0] == totalName {
+			total, hasTotal = count, true
+			continue
+		}
+		counters[fields[0]] = count
+		sum += count
+	}
+	if !hasTotal {
+		total = sum
+	}
+	return counters, total
+}
+
+// Problems describes why the device is unhealthy: AER errors, correctable
+// errors above the threshold, or a D3 power state while a driver is bound.
+// Unbound devices, and devices runtime suspended by their driver while idle,
+// are expected to be in D3.
+func (h *Health) Problems(correctableThreshold uint64) []string {
+	problems := []string{}
+	if h == nil {
+		return problems
+	}
+	if h.FatalTotal > 0 {
+		problems = append(problems, fmt.Sprintf("%d fatal errors%s", h.FatalTotal, formatCounters(h.Fatal)))
+	}
+	if h.NonFatalTotal > 0 {
+		problems = append(problems, fmt.Sprintf("%d non-fatal errors%s", h.NonFatalTotal, formatCounters(h.NonFatal)))
+	}
+	if h.CorrectableTotal > correctableThreshold {
+		problems = append(problems, fmt.Sprintf("%d correctable errors%s", h.CorrectableTotal, formatCounters(h.Correctable)))
+	}
+	if h.DriverBound && h.RuntimeStatus != "suspended" && strings.HasPrefix(h.PowerState, "D3") {
+		problems = append(problems, "power state "+h.PowerState)
+	}
+	return problems
+}
+
+// formatCounters lists the non-zero counters, e.g. " (BadTLP 2, RxErr 1)".
+func formatCounters(counters map[string]uint64) string {
+	names := []string{}
+	for name, count := range counters {
+		if count > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	slices.Sort(names)
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%s %d", name, counters[name])
+	}
+	return " (" + strings.Join(names, ", ") + ")"
+}
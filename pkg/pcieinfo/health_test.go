@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadHealth(t *testing.T) {
+	withHealth := func(device testDevice, files map[string]string) testDevice {
+		for name, data := range files {
+			device.files[name] = data
+		}
+		return device
+	}
+	unbound := func(device testDevice) testDevice {
+		delete(device.links, "driver")
+		return device
+	}
+	newTestHostRoot(t, []testDevice{
+		withHealth(gpu("pci0000:00/0000:00:01.0", "0"), map[string]string{
+			"enable":      "1\n",
+			"power_state": "D0\n",
+			"aer_dev_correctable": "RxErr 0\nBadTLP 3\nBadDLLP 1\nRollover 0\nTimeout 0\n" +
+				"NonFatalErr 0\nCorrIntErr 0\nHeaderOF 0\nTOTAL_ERR_COR 4\n",
+			"aer_dev_nonfatal": "Undefined 0\nDLP 0\nCmpltTO 1\nTOTAL_ERR_NONFATAL 1\n",
+			"aer_dev_fatal":    "Undefined 0\nDLP 0\nTOTAL_ERR_FATAL 0\n",
+		}),
+		withHealth(gpu("pci0000:00/0000:00:02.0", "0"), map[string]string{
+			"enable":      "0\n",
+			"power_state": "D3cold\n",
+		}),
+		gpu("pci0000:00/0000:00:03.0", "0"),
+		unbound(withHealth(gpu("pci0000:00/0000:00:04.0", "0"), map[string]string{
+			"enable":      "0\n",
+			"power_state": "D3hot\n",
+		})),
+		withHealth(gpu("pci0000:00/0000:00:05.0", "0"), map[string]string{
+			"enable":               "1\n",
+			"power_state":          "D3hot\n",
+			"power/runtime_status": "suspended\n",
+		}),
+	})
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	tests := []struct {
+		address      string
+		want         *Health
+		wantProblems []string
+	}{
+		{
+			address: "0000:00:01.0",
+			want: &Health{
+				Enabled:     true,
+				DriverBound: true,
+				PowerState:  "D0",
+				Correctable: map[string]uint64{
+					"RxErr": 0, "BadTLP": 3, "BadDLLP": 1, "Rollover": 0, "Timeout": 0,
+					"NonFatalErr": 0, "CorrIntErr": 0, "HeaderOF": 0,
+				},
+				NonFatal:         map[string]uint64{"Undefined": 0, "DLP": 0, "CmpltTO": 1},
+				Fatal:            map[string]uint64{"Undefined": 0, "DLP": 0},
+				CorrectableTotal: 4,
+				NonFatalTotal:    1,
+			},
+			wantProblems: []string{
+				"1 non-fatal errors (CmpltTO 1)",
+				"4 correctable errors (BadDLLP 1, BadTLP 3)",
+			},
+		},
+		{
+			address:      "0000:00:02.0",
+			want:         &Health{DriverBound: true, PowerState: "D3cold"},
+			wantProblems: []string{"power state D3cold"},
+		},
+		{
+			// Unbound devices are runtime suspended by the PCI core.
+			address:      "0000:00:04.0",
+			want:         &Health{PowerState: "D3hot"},
+			wantProblems: []string{},
+		},
+		{
+			// Idle devices are runtime suspended by their driver.
+			address:      "0000:00:05.0",
+			want:         &Health{Enabled: true, DriverBound: true, PowerState: "D3hot", RuntimeStatus: "suspended"},
+			wantProblems: []string{},
+		},
+		{
+			address:      "0000:00:03.0",
+			want:         nil,
+			wantProblems: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			device, ok := pcieInfo.FindDeviceByAddress(tt.address)
+			if !ok {
+				t.Fatalf("FindDeviceByAddress() found = false, want true")
+			}
+			if !reflect.DeepEqual(device.Health, tt.want) {
+				t.Errorf("Health = %+v, want %+v", device.Health, tt.want)
+			}
+			if got := device.Health.Problems(0); !reflect.DeepEqual(got, tt.wantProblems) {
+				t.Errorf("Health.Problems() = %v, want %v", got, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestHealth_Problems_threshold(t *testing.T) {
+	health := &Health{DriverBound: true, PowerState: "D0", CorrectableTotal: 4}
+	if got := health.Problems(4); len(got) != 0 {
+		t.Errorf("Health.Problems(4) = %v, want none", got)
+	}
+	if got := health.Problems(3); len(got) != 1 {
+		t.Errorf("Health.Problems(3) = %v, want 1 problem", got)
+	}
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+)
+
+// passthroughDrivers are the drivers a device may be bound to without
+// preventing the rest of its IOMMU group from being assigned through VFIO.
+var passthroughDrivers = map[string]bool{
+	"":         true,
+	"vfio-pci": true,
+	"pci-stub": true,
+	"pcieport": true,
+}
+
+// IOMMUGroup is a set of devices that can only be assigned together.
+type IOMMUGroup struct {
+	ID string `json:"id"`
+
+	// Devices are the addresses of the members, ordered by address.
+	Devices []string `json:"devices"`
+
+	// Viable is true when every member is bound to vfio-pci or is otherwise
+	// safe for passthrough (unbound, pci-stub or a PCIe port).
+	Viable bool `json:"viable"`
+
+	// Blockers are the members preventing passthrough, as "address (driver)".
+	Blockers []string `json:"blockers,omitempty"`
+}
+
+// readIOMMUGroupDevices returns the addresses of the members of the IOMMU
+// group, ordered by address.
+func readIOMMUGroupDevices(group string) []string {
+	if group == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(cpuinfo.HostSys(filepath.Join("kernel/iommu_groups", group, "devices")))
+	if err != nil {
+		return nil
+	}
+	devices := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		devices = append(devices, entry.Name())
+	}
+	slices.Sort(devices)
+	return devices
+}
+
+// IOMMUGroups returns the IOMMU groups of the devices, ordered by group ID.
+func (p *PCIEInfo) IOMMUGroups() []IOMMUGroup {
+	members := make(map[string]map[string]bool)
+	for _, device := range p.byAddress {
+		if device.IOMMUGroup == "" {
+			continue
+		}
+		if members[device.IOMMUGroup] == nil {
+			members[device.IOMMUGroup] = make(map[string]bool)
+		}
+		members[device.IOMMUGroup][device.Address] = true
+		for _, address := range device.IOMMUGroupDevices {
+			members[device.IOMMUGroup][address] = true
+		}
+	}
+
+	groups := make([]IOMMUGroup, 0, len(members))
+	for id, addresses := range members {
+		group := IOMMUGroup{
+			ID:      id,
+			Devices: make([]string, 0, len(addresses)),
+			Viable:  true,
+		}
+		for address := range addresses {
+			group.Devices = append(group.Devices, address)
+		}
+		slices.Sort(group.Devices)
+		for _, address := range group.Devices {
+			device, ok := p.byAddress[address]
+			if !ok {
+				// Without the driver of the member, assume the worst.
+				group.Viable = false
+				group.Blockers = append(group.Blockers, address+" (unknown)")
+				continue
+			}
+			if !passthroughDrivers[device.Driver] {
+				group.Viable = false
+				group.Blockers = append(group.Blockers, address+" ("+device.Driver+")")
+			}
+		}
+		groups = append(groups, group)
+	}
+	slices.SortFunc(groups, func(a, b IOMMUGroup) int {
+		return compareGroupIDs(a.ID, b.ID)
+	})
+	return groups
+}
+
+// IOMMUGroupOf returns the IOMMU group of the device, i.e. the devices that
+// must be passed through together with it.
+func (p *PCIEInfo) IOMMUGroupOf(address string) (IOMMUGroup, bool) {
+	device, ok := p.byAddress[address]
+	if !ok || device.IOMMUGroup == "" {
+		return IOMMUGroup{}, false
+	}
+	for _, group := range p.IOMMUGroups() {
+		if group.ID == device.IOMMUGroup {
+			return group, true
+		}
+	}
+	return IOMMUGroup{}, false
+}
+
+// compareGroupIDs orders numeric group IDs numerically.
+func compareGroupIDs(a, b string) int {
+	numA, errA := strconv.Atoi(a)
+	numB, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return numA - numB
+	}
+	return strings.Compare(a, b)
+}
+
+// ACS is the Access Control Services capability of a PCIe port.
+type ACS struct {
+	// Capabilities and Controls are the supported and enabled ACS features
+	// (e.g. "SrcValid", "ReqRedir").
+	Capabilities []string `json:"capabilities"`
+	Controls     []string `json:"controls"`
+}
+
+const (
+	extCapOffset = 0x100
+	extCapIDACS  = 0x000d
+)
+
+// acsFeatures are the ACS capability and control register bits.
+var acsFeatures = []string{
+	"SrcValid",
+	"TransBlk",
+	"ReqRedir",
+	"CmpltRedir",
+	"UpstreamFwd",
+	"EgressCtrl",
+	"DirectTrans",
+}
+
+// readACS reads the ACS capability from the configuration space of the
+// device. It returns nil when the device has no ACS capability or when the
+// extended configuration space is not readable (it requires root).
+func readACS(devicePath string) *ACS {
+	config, err := os.ReadFile(filepath.Join(devicePath, "config"))
+	if err != nil {
+		return nil
+	}
+	offset, ok := findExtCapability(config, extCapIDACS)
+	if !ok {
+		return nil
+	}
+	capability := binary.LittleEndian.Uint16(config[offset+4:])
+	control := binary.LittleEndian.Uint16(config[offset+6:])
+	return &ACS{
+		Capabilities: acsFlags(capability),
+		Controls:     acsFlags(control),
+	}
+}
+
+// findExtCapability walks the PCIe extended capabilities of the config space
+// and returns the offset of the capability with the ID. At least 8 bytes of
+// the capability are available at the offset.
+func findExtCapability(config []byte, id uint32) (int, bool) {
+	offset := extCapOffset
+	for visited := 0; offset >= extCapOffset && offset+8 <= len(config) && visited < 1024; visited++ {
+		header := binary.LittleEndian.Uint32(config[offset:])
+		if header == 0 || header == 0xffffffff {
+			return 0, false
+		}
+		if header&0xffff == id {
+			return offset, true
+		}
+		offset = int(header>>20) &^ 0x3
+	}
+	return 0, false
+}
+
+func acsFlags(register uint16) []string {
+	flags := []string{}
+	for bit, name := range acsFeatures {
+		if register&(1<<bit) != 0 {
+			flags = append(flags, name)
+		}
+	}
+	return flags
+}
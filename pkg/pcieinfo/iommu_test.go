@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// addIOMMUGroup links the devices into the IOMMU group of the fake sysfs.
+func addIOMMUGroup(t *testing.T, hostRoot string, group string, addresses ...string) {
+	t.Helper()
+	groupPath := filepath.Join(hostRoot, "sys/kernel/iommu_groups", group, "devices")
+	if err := os.MkdirAll(groupPath, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	for _, address := range addresses {
+		target := filepath.Join(hostRoot, "sys/bus/pci/devices", address)
+		if err := os.Symlink(target, filepath.Join(groupPath, address)); err != nil {
+			t.Fatalf("Symlink() error = %v", err)
+		}
+	}
+}
+
+func TestIOMMUGroups(t *testing.T) {
+	withGroup := func(device testDevice, group string, driver string) testDevice {
+		device.links["iommu_group"] = "kernel/iommu_groups/" + group
+		if driver == "" {
+			delete(device.links, "driver")
+		} else {
+			device.links["driver"] = "bus/pci/drivers/" + driver
+		}
+		return device
+	}
+	hostRoot := newTestHostRoot(t, []testDevice{
+		withGroup(bridge("pci0000:00/0000:00:01.0"), "2", "pcieport"),
+		withGroup(gpu("pci0000:00/0000:00:01.0/0000:01:00.0", "0"), "2", "vfio-pci"),
+		withGroup(gpu("pci0000:00/0000:00:01.0/0000:01:00.1", "0"), "2", ""),
+		withGroup(gpu("pci0000:80/0000:80:01.0", "1"), "10", "nvidia"),
+		withGroup(gpu("pci0000:80/0000:80:02.0", "1"), "10", "vfio-pci"),
+	})
+	addIOMMUGroup(t, hostRoot, "2", "0000:00:01.0", "0000:01:00.0", "0000:01:00.1")
+	addIOMMUGroup(t, hostRoot, "10", "0000:80:01.0", "0000:80:02.0")
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	want := []IOMMUGroup{
+		{
+			ID:      "2",
+			Devices: []string{"0000:00:01.0", "0000:01:00.0", "0000:01:00.1"},
+			Viable:  true,
+		},
+		{
+			ID:       "10",
+			Devices:  []string{"0000:80:01.0", "0000:80:02.0"},
+			Viable:   false,
+			Blockers: []string{"0000:80:01.0 (nvidia)"},
+		},
+	}
+	if got := pcieInfo.IOMMUGroups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("IOMMUGroups() = %+v, want %+v", got, want)
+	}
+
+	got, ok := pcieInfo.IOMMUGroupOf("0000:80:02.0")
+	if !ok || !reflect.DeepEqual(got, want[1]) {
+		t.Errorf("IOMMUGroupOf() = %+v, %v, want %+v, true", got, ok, want[1])
+	}
+}
+
+func TestIOMMUGroups_missingMember(t *testing.T) {
+	// The group lists a member that is not in the inventory.
+	pcieInfo := NewPCIEInfoFromDevices([]PCIEDeviceInfo{
+		{
+			Address:           "0000:01:00.0",
+			Driver:            "vfio-pci",
+			IOMMUGroup:        "5",
+			IOMMUGroupDevices: []string{"0000:01:00.0", "0000:01:00.1"},
+		},
+	})
+	want := []IOMMUGroup{
+		{
+			ID:       "5",
+			Devices:  []string{"0000:01:00.0", "0000:01:00.1"},
+			Viable:   false,
+			Blockers: []string{"0000:01:00.1 (unknown)"},
+		},
+	}
+	if got := pcieInfo.IOMMUGroups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("IOMMUGroups() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadACS(t *testing.T) {
+	// testConfig returns a 4 KiB configuration space with a vendor specific
+	// capability at 0x100 followed by ACS at 0x140.
+	testConfig := func(capability, control uint16) []byte {
+		config := make([]byte, 4096)
+		binary.LittleEndian.PutUint32(config[0x100:], 0x000b|1<<16|0x140<<20)
+		binary.LittleEndian.PutUint32(config[0x140:], extCapIDACS|1<<16)
+		binary.LittleEndian.PutUint16(config[0x144:], capability)
+		binary.LittleEndian.PutUint16(config[0x146:], control)
+		return config
+	}
+
+	tests := []struct {
+		name   string
+		config []byte
+		want   *ACS
+	}{
+		{
+			name:   "acs",
+			config: testConfig(0x5f, 0x1d),
+			want: &ACS{
+				Capabilities: []string{"SrcValid", "TransBlk", "ReqRedir", "CmpltRedir", "UpstreamFwd", "DirectTrans"},
+				Controls:     []string{"SrcValid", "ReqRedir", "CmpltRedir", "UpstreamFwd"},
+			},
+		},
+		{
+			name:   "unprivileged",
+			config: testConfig(0x5f, 0x1d)[:64],
+			want:   nil,
+		},
+		{
+			name:   "no extended capabilities",
+			config: make([]byte, 4096),
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devicePath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(devicePath, "config"), tt.config, 0o644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if got := readACS(devicePath); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readACS() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PCIELink holds the negotiated and maximum link of a PCIe device.
+type PCIELink struct {
+	// CurrentSpeed is the negotiated speed (e.g. "8.0 GT/s PCIe").
+	CurrentSpeed string `json:"currentSpeed"`
+	CurrentWidth int    `json:"currentWidth"`
+	MaxSpeed     string `json:"maxSpeed"`
+	MaxWidth     int    `json:"maxWidth"`
+
+	// UpstreamMaxSpeed and UpstreamMaxWidth are the maximum link of the
+	// upstream port (e.g. the root port of the slot), when known.
+	UpstreamMaxSpeed string `json:"upstreamMaxSpeed,omitempty"`
+	UpstreamMaxWidth int    `json:"upstreamMaxWidth,omitempty"`
+
+	// Degraded is true when the link trained narrower or slower than both
+	// the device and the upstream port support.
+	Degraded bool `json:"degraded"`
+}
+
+// readPCIELink reads the link attributes of the device, returning nil when the
+// device does not expose them (e.g. conventional PCI devices). The upstream
+// port is the parent of the device in sysfs.
+func readPCIELink(devicePath string) *PCIELink {
+	currentSpeed, err := readFile(filepath.Join(devicePath, "current_link_speed"))
+	if err != nil {
+		return nil
+	}
+	maxSpeed, _ := readFile(filepath.Join(devicePath, "max_link_speed"))
+	currentWidth, _ := readIntFromFile(filepath.Join(devicePath, "current_link_width"))
+	maxWidth, _ := readIntFromFile(filepath.Join(devicePath, "max_link_width"))
+
+	link := &PCIELink{
+		CurrentSpeed: strings.TrimSpace(currentSpeed),
+		CurrentWidth: currentWidth,
+		MaxSpeed:     strings.TrimSpace(maxSpeed),
+		MaxWidth:     maxWidth,
+	}
+	if realPath, err := filepath.EvalSymlinks(devicePath); err == nil {
+		upstreamPath := filepath.Dir(realPath)
+		upstreamSpeed, _ := readFile(filepath.Join(upstreamPath, "max_link_speed"))
+		upstreamWidth, _ := readIntFromFile(filepath.Join(upstreamPath, "max_link_width"))
+		link.UpstreamMaxSpeed = strings.TrimSpace(upstreamSpeed)
+		link.UpstreamMaxWidth = upstreamWidth
+	}
+	link.Degraded = len(link.Degradation()) > 0
+	return link
+}
+
+// Degradation describes how the link is below the lower of the device and
+// upstream port maximums. Unknown speeds and widths are not compared. Note
+// that some devices, such as GPUs, lower their link speed while idle to save
+// power.
+func (l PCIELink) Degradation() []string {
+	reasons := []string{}
+	current, currentOk := parseLinkSpeed(l.CurrentSpeed)
+	if capSpeed, capOk := l.capableSpeed(); currentOk && capOk && current < capSpeed {
+		reasons = append(reasons, fmt.Sprintf("speed %s (%s) below %s (%s)",
+			l.CurrentSpeed, LinkGeneration(current), formatLinkSpeed(capSpeed), LinkGeneration(capSpeed)))
+	}
+	if capWidth := l.capableWidth(); l.CurrentWidth > 0 && capWidth > 0 && l.CurrentWidth < capWidth {
+		reasons = append(reasons, fmt.Sprintf("width x%d below x%d", l.CurrentWidth, capWidth))
+	}
+	return reasons
+}
+
+// Limitation describes how the upstream port keeps the link below the device
+// maximum (e.g. an x16 card in an x8 slot). This is informational only.
+func (l PCIELink) Limitation() []string {
+	reasons := []string{}
+	maximum, maxOk := parseLinkSpeed(l.MaxSpeed)
+	if capSpeed, capOk := l.capableSpeed(); maxOk && capOk && capSpeed < maximum {
+		reasons = append(reasons, fmt.Sprintf("speed limited to %s (%s) by the upstream port",
+			formatLinkSpeed(capSpeed), LinkGeneration(capSpeed)))
+	}
+	if capWidth := l.capableWidth(); capWidth > 0 && capWidth < l.MaxWidth {
+		reasons = append(reasons, fmt.Sprintf("width limited to x%d by the upstream port", capWidth))
+	}
+	return reasons
+}
+
+// capableSpeed returns the lower of the known device and upstream port
+// maximum speeds in GT/s.
+func (l PCIELink) capableSpeed() (float64, bool) {
+	maximum, maxOk := parseLinkSpeed(l.MaxSpeed)
+	upstream, upstreamOk := parseLinkSpeed(l.UpstreamMaxSpeed)
+	switch {
+	case maxOk && upstreamOk:
+		return min(maximum, upstream), true
+	case maxOk:
+		return maximum, true
+	default:
+		return upstream, upstreamOk
+	}
+}
+
+// capableWidth returns the lower of the known device and upstream port
+// maximum widths, or 0 when neither is known.
+func (l PCIELink) capableWidth() int {
+	if l.MaxWidth > 0 && l.UpstreamMaxWidth > 0 {
+		return min(l.MaxWidth, l.UpstreamMaxWidth)
+	}
+	return max(l.MaxWidth, l.UpstreamMaxWidth)
+}
+
+// formatLinkSpeed formats GT/s like sysfs (e.g. "16.0 GT/s PCIe").
+func formatLinkSpeed(gts float64) string {
+	return strconv.FormatFloat(gts, 'f', 1, 64) + " GT/s PCIe"
+}
+
+// parseLinkSpeed parses the GT/s of a sysfs link speed (e.g. "16.0 GT/s PCIe").
+func parseLinkSpeed(speed string) (float64, bool) {
+	fields := strings.Fields(speed)
+	if len(fields) < 2 || fields[1] != "GT/s" {
+		return 0, false
+	}
+	gts, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return gts, true
+}
+
+// LinkGeneration returns the PCIe generation of the speed in GT/s (e.g. "Gen3").
+func LinkGeneration(gts float64) string {
+	generations := []float64{2.5, 5, 8, 16, 32, 64}
+	for idx, speed := range generations {
+		if gts <= speed {
+			return "Gen" + strconv.Itoa(idx+1)
+		}
+	}
+	return "Gen" + strconv.Itoa(len(generations)+1)
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPCIELink_Degradation(t *testing.T) {
+	tests := []struct {
+		name string
+		link PCIELink
+		want []string
+	}{
+		{
+			name: "full link",
+			link: PCIELink{CurrentSpeed: "16.0 GT/s PCIe", CurrentWidth: 16, MaxSpeed: "16.0 GT/s PCIe", MaxWidth: 16},
+			want: []string{},
+		},
+		{
+			name: "slow and narrow",
+			link: PCIELink{CurrentSpeed: "8.0 GT/s PCIe", CurrentWidth: 8, MaxSpeed: "16.0 GT/s PCIe", MaxWidth: 16},
+			want: []string{
+				"speed 8.0 GT/s PCIe (Gen3) below 16.0 GT/s PCIe (Gen4)",
+				"width x8 below x16",
+			},
+		},
+		{
+			name: "slow upstream port",
+			link: PCIELink{CurrentSpeed: "8.0 GT/s PCIe", CurrentWidth: 16, MaxSpeed: "16.0 GT/s PCIe", MaxWidth: 16, UpstreamMaxSpeed: "8.0 GT/s PCIe", UpstreamMaxWidth: 16},
+			want: []string{},
+		},
+		{
+			name: "narrow upstream port",
+			link: PCIELink{CurrentSpeed: "16.0 GT/s PCIe", CurrentWidth: 4, MaxSpeed: "16.0 GT/s PCIe", MaxWidth: 16, UpstreamMaxSpeed: "16.0 GT/s PCIe", UpstreamMaxWidth: 8},
+			want: []string{"width x4 below x8"},
+		},
+		{
+			name: "unknown speed",
+			link: PCIELink{CurrentSpeed: "Unknown", CurrentWidth: 0, MaxSpeed: "2.5 GT/s PCIe", MaxWidth: 1},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.link.Degradation(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PCIELink.Degradation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPCIELink_Limitation(t *testing.T) {
+	tests := []struct {
+		name string
+		link PCIELink
+		want []string
+	}{
+		{
+			name: "no upstream port",
+			link: PCIELink{CurrentSpeed: "8.0 GT/s PCIe", CurrentWidth: 16, MaxSpeed: "16.0 GT/s PCIe", MaxWidth: 16},
+			want: []string{},
+		},
+		{
+			name: "slow and narrow upstream port",
+			link: PCIELink{CurrentSpeed: "8.0 GT/s PCIe", CurrentWidth: 8, MaxSpeed: "16.0 GT/s PCIe", MaxWidth: 16, UpstreamMaxSpeed: "8.0 GT/s PCIe", UpstreamMaxWidth: 8},
+			want: []string{
+				"speed limited to 8.0 GT/s PCIe (Gen3) by the upstream port",
+				"width limited to x8 by the upstream port",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.link.Limitation(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PCIELink.Limitation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPCIEInfo_link(t *testing.T) {
+	port := bridge("pci0000:00/0000:00:01.0")
+	port.files["max_link_speed"] = "8.0 GT/s PCIe\n"
+	port.files["max_link_width"] = "16\n"
+	device := gpu("pci0000:00/0000:00:01.0/0000:01:00.0", "0")
+	device.files["current_link_speed"] = "8.0 GT/s PCIe\n"
+	device.files["current_link_width"] = "8\n"
+	device.files["max_link_speed"] = "16.0 GT/s PCIe\n"
+	device.files["max_link_width"] = "16\n"
+	newTestHostRoot(t, []testDevice{port, device})
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+	got, _ := pcieInfo.FindDeviceByAddress("0000:01:00.0")
+	want := &PCIELink{
+		CurrentSpeed:     "8.0 GT/s PCIe",
+		CurrentWidth:     8,
+		MaxSpeed:         "16.0 GT/s PCIe",
+		MaxWidth:         16,
+		UpstreamMaxSpeed: "8.0 GT/s PCIe",
+		UpstreamMaxWidth: 16,
+		Degraded:         true,
+	}
+	if !reflect.DeepEqual(got.Link, want) {
+		t.Errorf("PCIEDeviceInfo.Link = %+v, want %+v", got.Link, want)
+	}
+	if bridge, _ := pcieInfo.FindDeviceByAddress("0000:00:01.0"); bridge.Link != nil {
+		t.Errorf("PCIEDeviceInfo.Link = %+v, want nil", bridge.Link)
+	}
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// NetInterface is a Linux network interface of a PCIe device.
+type NetInterface struct {
+	Name      string `json:"name"`
+	MAC       string `json:"mac,omitempty"`
+	MTU       int    `json:"mtu,omitempty"`
+	OperState string `json:"operState,omitempty"`
+
+	// Speed is in Mb/s, zero when unknown (e.g. the link is down).
+	Speed int `json:"speed,omitempty"`
+
+	RxQueues int `json:"rxQueues,omitempty"`
+	TxQueues int `json:"txQueues,omitempty"`
+
+	// RDMADevice is the RDMA device of the same PCI function (e.g. "mlx5_0").
+	RDMADevice string `json:"rdmaDevice,omitempty"`
+}
+
+// readRDMADevices reads the RDMA device names of the device, ordered by name.
+func readRDMADevices(devicePath string) []string {
+	entries, err := os.ReadDir(filepath.Join(devicePath, "infiniband"))
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// readNetInterfaces reads the network interfaces of the device, ordered by
+// name. The interfaces are paired with the first of the RDMA devices.
+func readNetInterfaces(devicePath string, rdmaDevices []string) []NetInterface {
+	entries, err := os.ReadDir(filepath.Join(devicePath, "net"))
+	if err != nil {
+		return nil
+	}
+
+	rdmaDevice := ""
+	if len(rdmaDevices) > 0 {
+		rdmaDevice = rdmaDevices[0]
+	}
+
+	interfaces := []NetInterface{}
+	for _, entry := range entries {
+		netPath := filepath.Join(devicePath, "net", entry.Name())
+		mac, _ := readFile(filepath.Join(netPath, "address"))
+		mtu, _ := readIntFromFile(filepath.Join(netPath, "mtu"))
+		operState, _ := readFile(filepath.Join(netPath, "operstate"))
+		// Reading the speed fails while the link is down.
+		speed, _ := readIntFromFile(filepath.Join(netPath, "speed"))
+		rxQueues, txQueues := countQueues(filepath.Join(netPath, "queues"))
+
+		interfaces = append(interfaces, NetInterface{
+			Name:       entry.Name(),
+			MAC:        strings.TrimSpace(mac),
+			MTU:        mtu,
+			OperState:  strings.TrimSpace(operState),
+			Speed:      max(speed, 0),
+			RxQueues:   rxQueues,
+			TxQueues:   txQueues,
+			RDMADevice: rdmaDevice,
+		})
+	}
+	slices.SortFunc(interfaces, func(a, b NetInterface) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return interfaces
+}
+
+// countQueues counts the rx-N and tx-N queues of a network interface.
+func countQueues(queuesPath string) (int, int) {
+	entries, err := os.ReadDir(queuesPath)
+	if err != nil {
+		return 0, 0
+	}
+	rxQueues, txQueues := 0, 0
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry.Name(), "rx-"):
+			rxQueues++
+		case strings.HasPrefix(entry.Name(), "tx-"):
+			txQueues++
+		}
+	}
+	return rxQueues, txQueues
+}
+
+// FindDeviceByInterface looks up a device by the name of one of its network
+// interfaces (e.g. "ens1f0").
+func (p *PCIEInfo) FindDeviceByInterface(name string) (PCIEDeviceInfo, NetInterface, bool) {
+	for _, device := range p.byAddress {
+		for _, netInterface := range device.NetInterfaces {
+			if netInterface.Name == name {
+				return device, netInterface, true
+			}
+		}
+	}
+	return PCIEDeviceInfo{}, NetInterface{}, false
+}
+
+// FindDeviceByRDMADevice looks up a device by its RDMA device name (e.g.
+// "mlx5_0").
+func (p *PCIEInfo) FindDeviceByRDMADevice(name string) (PCIEDeviceInfo, bool) {
+	for _, device := range p.byAddress {
+		if slices.Contains(device.RDMADevices, name) {
+			return device, true
+		}
+	}
+	return PCIEDeviceInfo{}, false
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNetInterfaces(t *testing.T) {
+	newTestHostRoot(t, []testDevice{
+		nic("pci0000:80/0000:80:01.0", map[string]string{
+			"net/ens1f0/address":          "b8:ce:f6:01:02:03\n",
+			"net/ens1f0/mtu":              "9000\n",
+			"net/ens1f0/operstate":        "up\n",
+			"net/ens1f0/speed":            "100000\n",
+			"net/ens1f0/queues/rx-0/x":    "",
+			"net/ens1f0/queues/rx-1/x":    "",
+			"net/ens1f0/queues/tx-0/x":    "",
+			"net/ens1f0/queues/tx-1/x":    "",
+			"net/ens1f0/queues/tx-2/x":    "",
+			"infiniband/mlx5_0/node_type": "1: CA\n",
+		}, map[string]string{"driver": "bus/pci/drivers/mlx5_core"}),
+		nic("pci0000:80/0000:80:02.0", map[string]string{
+			"net/ens2/address":   "b8:ce:f6:01:02:04\n",
+			"net/ens2/mtu":       "1500\n",
+			"net/ens2/operstate": "down\n",
+			// The kernel reports -1 while the link is down.
+			"net/ens2/speed": "-1\n",
+		}, nil),
+	})
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		want        NetInterface
+		wantAddress string
+	}{
+		{
+			name: "ens1f0",
+			want: NetInterface{
+				Name:       "ens1f0",
+				MAC:        "b8:ce:f6:01:02:03",
+				MTU:        9000,
+				OperState:  "up",
+				Speed:      100000,
+				RxQueues:   2,
+				TxQueues:   3,
+				RDMADevice: "mlx5_0",
+			},
+			wantAddress: "0000:80:01.0",
+		},
+		{
+			name: "ens2",
+			want: NetInterface{
+				Name:      "ens2",
+				MAC:       "b8:ce:f6:01:02:04",
+				MTU:       1500,
+				OperState: "down",
+			},
+			wantAddress: "0000:80:02.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device, got, ok := pcieInfo.FindDeviceByInterface(tt.name)
+			if !ok {
+				t.Fatalf("FindDeviceByInterface() found = false, want true")
+			}
+			if device.Address != tt.wantAddress {
+				t.Errorf("FindDeviceByInterface() address = %v, want %v", device.Address, tt.wantAddress)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDeviceByInterface() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, ok := pcieInfo.FindDeviceByInterface("lo"); ok {
+		t.Errorf("FindDeviceByInterface(lo) found = true, want false")
+	}
+	device, ok := pcieInfo.FindDeviceByRDMADevice("mlx5_0")
+	if !ok || device.Address != "0000:80:01.0" {
+		t.Errorf("FindDeviceByRDMADevice() = %v, %v, want 0000:80:01.0, true", device.Address, ok)
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+)
+
+// readNodeCPUs reads the CPUs of every NUMA node.
+func readNodeCPUs() map[int]cpuset.CPUSet {
+	nodeCpus := make(map[int]cpuset.CPUSet)
+	nodePath := cpuinfo.HostSys("devices/system/node")
+	entries, err := os.ReadDir(nodePath)
+	if err != nil {
+		return nodeCpus
+	}
+	for _, entry := range entries {
+		nodeId, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), "node"))
+		if err != nil || !strings.HasPrefix(entry.Name(), "node") {
+			continue
+		}
+		cpuList, err := readFile(filepath.Join(nodePath, entry.Name(), "cpulist"))
+		if err != nil {
+			continue
+		}
+		cpus, err := cpuset.Parse(strings.TrimSpace(cpuList))
+		if err != nil {
+			continue
+		}
+		nodeCpus[nodeId] = cpus
+	}
+	return nodeCpus
+}
+
+// inferNUMANode returns the NUMA node holding all the CPUs of the local CPU
+// mask. It returns UnknownNUMANode and false when the mask is empty or spans
+// several nodes.
+func inferNUMANode(mask string, nodeCpus map[int]cpuset.CPUSet) (int, bool) {
+	bm, err := bitmaputil.NewFrom(mask)
+	if err != nil || bm.Count() == 0 {
+		return UnknownNUMANode, false
+	}
+	localCpus := cpuset.New(bitmaputil.List(bm)...)
+
+	numaNode := UnknownNUMANode
+	for nodeId, cpus := range nodeCpus {
+		if cpus.Intersection(localCpus).IsEmpty() {
+			continue
+		}
+		if numaNode != UnknownNUMANode {
+			return UnknownNUMANode, false
+		}
+		numaNode = nodeId
+	}
+	if numaNode == UnknownNUMANode || !localCpus.IsSubsetOf(nodeCpus[numaNode]) {
+		return UnknownNUMANode, false
+	}
+	return numaNode, true
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/internal/sysfstest"
+)
+
+func TestInferNUMANode(t *testing.T) {
+	nodeCpus := map[int]cpuset.CPUSet{
+		0: cpuset.New(0, 1, 2, 3, 8, 9, 10, 11),
+		1: cpuset.New(4, 5, 6, 7, 12, 13, 14, 15),
+	}
+
+	tests := []struct {
+		name         string
+		mask         string
+		nodeCpus     map[int]cpuset.CPUSet
+		want         int
+		wantInferred bool
+	}{
+		{name: "node 0", mask: "0x0f0f", nodeCpus: nodeCpus, want: 0, wantInferred: true},
+		{name: "node 1", mask: "0x00f0", nodeCpus: nodeCpus, want: 1, wantInferred: true},
+		{name: "spans nodes", mask: "0xffff", nodeCpus: nodeCpus, want: UnknownNUMANode},
+		{name: "empty mask", mask: "0x", nodeCpus: nodeCpus, want: UnknownNUMANode},
+		{name: "CPUs outside any node", mask: "0x10000", nodeCpus: nodeCpus, want: UnknownNUMANode},
+		{name: "no nodes", mask: "0x0f0f", nodeCpus: map[int]cpuset.CPUSet{}, want: UnknownNUMANode},
+		{name: "single node", mask: "0xffff", nodeCpus: map[int]cpuset.CPUSet{0: cpuset.New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15)}, want: 0, wantInferred: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, inferred := inferNUMANode(tt.mask, tt.nodeCpus)
+			if got != tt.want || inferred != tt.wantInferred {
+				t.Errorf("inferNUMANode() = %v, %v, want %v, %v", got, inferred, tt.want, tt.wantInferred)
+			}
+		})
+	}
+}
+
+func TestNewPCIEInfo_numaNode(t *testing.T) {
+	withoutNUMANode := func(device testDevice, localCpus string) testDevice {
+		delete(device.files, "numa_node")
+		device.files["local_cpus"] = localCpus
+		return device
+	}
+	hostRoot := newTestHostRoot(t, []testDevice{
+		// The kernel reports the node.
+		gpu("pci0000:00/0000:00:01.0", "0"),
+		// The firmware does not tell the node, local_cpus does.
+		gpu("pci0000:80/0000:80:01.0", "-1"),
+		// Neither the kernel nor local_cpus tell the node.
+		withoutNUMANode(gpu("pci0000:80/0000:80:02.0", "-1"), "00000000,0000ffff\n"),
+		withoutNUMANode(gpu("pci0000:80/0000:80:03.0", "-1"), "00000000,00000000\n"),
+	})
+	sysfstest.WriteFile(t, hostRoot, "sys/devices/system/node/node0/cpulist", "0-7\n")
+	sysfstest.WriteFile(t, hostRoot, "sys/devices/system/node/node1/cpulist", "8-15\n")
+	sysfstest.WriteFile(t, hostRoot, "sys/devices/pci0000:80/0000:80:01.0/local_cpus", "00000000,0000ff00\n")
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	tests := []struct {
+		address      string
+		want         int
+		wantInferred bool
+	}{
+		{address: "0000:00:01.0", want: 0},
+		{address: "0000:80:01.0", want: 1, wantInferred: true},
+		{address: "0000:80:02.0", want: UnknownNUMANode},
+		{address: "0000:80:03.0", want: UnknownNUMANode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			device, ok := pcieInfo.FindDeviceByAddress(tt.address)
+			if !ok {
+				t.Fatalf("FindDeviceByAddress() found = false, want true")
+			}
+			if device.NUMANode != tt.want || device.NUMANodeInferred != tt.wantInferred {
+				t.Errorf("NUMANode = %v (inferred %v), want %v (inferred %v)",
+					device.NUMANode, device.NUMANodeInferred, tt.want, tt.wantInferred)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+)
+
+// NVMeController is the NVMe controller of a PCIe device.
+type NVMeController struct {
+	// Name is the controller name (e.g. "nvme0").
+	Name     string `json:"name"`
+	Model    string `json:"model,omitempty"`
+	Serial   string `json:"serial,omitempty"`
+	Firmware string `json:"firmware,omitempty"`
+
+	// Namespaces are ordered by name.
+	Namespaces []NVMeNamespace `json:"namespaces,omitempty"`
+}
+
+// NVMeNamespace is a namespace of an NVMe controller.
+type NVMeNamespace struct {
+	// Name is the sysfs name (e.g. "nvme0n1", or "nvme0c0n1" for a path of
+	// a multipath namespace).
+	Name string `json:"name"`
+
+	// BlockDevice is the block device of the namespace (e.g. "nvme0n1").
+	BlockDevice string `json:"blockDevice"`
+
+	// SizeBytes is the capacity of the namespace.
+	SizeBytes uint64 `json:"sizeBytes"`
+
+	// Partitions are the block devices of the partitions, ordered by name.
+	Partitions []string `json:"partitions,omitempty"`
+}
+
+var (
+	nvmeControllerRegexp = regexp.MustCompile(`^nvme[0-9]+$`)
+	nvmeNamespaceRegexp  = regexp.MustCompile(`^nvme([0-9]+)(c[0-9]+)?n([0-9]+)$`)
+)
+
+// sectorSize is the unit of the sysfs block device `size` attribute.
+const sectorSize = 512
+
+// readNVMeController reads the NVMe controller of the device, returning nil
+// for devices that are not NVMe controllers.
+func readNVMeController(devicePath string) *NVMeController {
+	entries, err := os.ReadDir(filepath.Join(devicePath, "nvme"))
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if !nvmeControllerRegexp.MatchString(entry.Name()) {
+			continue
+		}
+		controllerPath := filepath.Join(devicePath, "nvme", entry.Name())
+		model, _ := readFile(filepath.Join(controllerPath, "model"))
+		serial, _ := readFile(filepath.Join(controllerPath, "serial"))
+		firmware, _ := readFile(filepath.Join(controllerPath, "firmware_rev"))
+		return &NVMeController{
+			Name:       entry.Name(),
+			Model:      strings.TrimSpace(model),
+			Serial:     strings.TrimSpace(serial),
+			Firmware:   strings.TrimSpace(firmware),
+			Namespaces: readNVMeNamespaces(controllerPath),
+		}
+	}
+	return nil
+}
+
+func readNVMeNamespaces(controllerPath string) []NVMeNamespace {
+	entries, err := os.ReadDir(controllerPath)
+	if err != nil {
+		return nil
+	}
+	namespaces := []NVMeNamespace{}
+	for _, entry := range entries {
+		match := nvmeNamespaceRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		namespacePath := filepath.Join(controllerPath, entry.Name())
+		sectors, _ := readUint64FromFile(filepath.Join(namespacePath, "size"))
+		namespace := NVMeNamespace{
+			Name: entry.Name(),
+			// A multipath path device (nvmeXcYnZ) is served by the shared
+			// block device nvmeXnZ.
+			BlockDevice: "nvme" + match[1] + "n" + match[3],
+			SizeBytes:   sectors * sectorSize,
+		}
+		if partitions, err := os.ReadDir(namespacePath); err == nil {
+			for _, partition := range partitions {
+				if strings.HasPrefix(partition.Name(), entry.Name()+"p") {
+					namespace.Partitions = append(namespace.Partitions, partition.Name())
+				}
+			}
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	slices.SortFunc(namespaces, func(a, b NVMeNamespace) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return namespaces
+}
+
+func readUint64FromFile(filename string) (uint64, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(data), 10, 64)
+}
+
+// FindDevicesByBlockDevice returns the PCIe devices backing the block device
+// (e.g. "nvme0n1", "/dev/sda1", "/dev/mapper/vg-lv" or "dm-0"), ordered by
+// address. Stacked devices, such as device mapper or MD RAID, resolve to the
+// devices of all their members.
+func (p *PCIEInfo) FindDevicesByBlockDevice(name string) ([]PCIEDeviceInfo, error) {
+	blockDevice := filepath.Base(name)
+	if strings.HasPrefix(name, "/dev/") {
+		// Follow links such as /dev/mapper/vg-lv or /dev/disk/by-id/... to
+		// the kernel name of the device.
+		realPath, err := filepath.EvalSymlinks(cpuinfo.HostRoot(name))
+		if err != nil {
+			return nil, fmt.Errorf("block device %s not found: %w", name, err)
+		}
+		blockDevice = filepath.Base(realPath)
+	}
+
+	found := make(map[string]PCIEDeviceInfo)
+	if err := p.findDevicesByBlockDevice(blockDevice, found, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no PCIe device found for block device %s", name)
+	}
+	devices := make([]PCIEDeviceInfo, 0, len(found))
+	for _, device := range found {
+		devices = append(devices, device)
+	}
+	slices.SortFunc(devices, func(a, b PCIEDeviceInfo) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	return devices, nil
+}
+
+func (p *PCIEInfo) findDevicesByBlockDevice(name string, found map[string]PCIEDeviceInfo, visited map[string]bool) error {
+	if visited[name] {
+		return nil
+	}
+	visited[name] = true
+
+	// Multipath NVMe namespaces live under a virtual subsystem device, so
+	// look for the controllers serving them first.
+	isNamespace := false
+	for _, device := range p.byAddress {
+		if device.NVMe == nil {
+			continue
+		}
+		for _, namespace := range device.NVMe.Namespaces {
+			if namespace.BlockDevice == name || namespace.Name == name || strings.HasPrefix(name, namespace.BlockDevice+"p") {
+				found[device.Address] = device
+				isNamespace = true
+			}
+		}
+	}
+	if isNamespace {
+		return nil
+	}
+
+	blockPath := cpuinfo.HostSys("class/block", name)
+	realPath, err := filepath.EvalSymlinks(blockPath)
+	if err != nil {
+		return fmt.Errorf("block device %s not found: %w", name, err)
+	}
+	// The nearest PCI device above the block device is its controller.
+	components := strings.Split(realPath, string(filepath.Separator))
+	for i := len(components) - 1; i >= 0; i-- {
+		if device, ok := p.byAddress[components[i]]; ok {
+			found[device.Address] = device
+			return nil
+		}
+	}
+
+	// Partitions of stacked devices list their members on the whole device.
+	slavesPath := filepath.Join(realPath, "slaves")
+	if _, err := os.Stat(slavesPath); os.IsNotExist(err) {
+		slavesPath = filepath.Join(filepath.Dir(realPath), "slaves")
+	}
+	slaves, err := os.ReadDir(slavesPath)
+	if err != nil {
+		return nil
+	}
+	for _, slave := range slaves {
+		if err := p.findDevicesByBlockDevice(slave.Name(), found, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindDevicesByPath returns the PCIe devices backing the filesystem holding
+// the path (e.g. a mount point), ordered by address. Mounts are read from the
+// mount namespace of the calling process.
+func (p *PCIEInfo) FindDevicesByPath(path string) ([]PCIEDeviceInfo, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	devNum, err := findMountDevice(cpuinfo.HostProc("self/mountinfo"), path)
+	if err != nil {
+		return nil, err
+	}
+	realPath, err := filepath.EvalSymlinks(cpuinfo.HostSys("dev/block", devNum))
+	if err != nil {
+		return nil, fmt.Errorf("filesystem of %s is not on a block device (%s)", path, devNum)
+	}
+	return p.FindDevicesByBlockDevice(filepath.Base(realPath))
+}
+
+// findMountDevice returns the device number ("major:minor") of the mount
+// holding the path.
+func findMountDevice(mountInfoPath string, path string) (string, error) {
+	f, err := os.Open(mountInfoPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	devNum := ""
+	longest := -1
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// See proc(5): the device number and mount point are the third and
+		// fifth fields.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mountPoint := unescapeMountPoint(fields[4])
+		if !isPathUnder(path, mountPoint) || len(mountPoint) < longest {
+			continue
+		}
+		// Later mounts on the same mount point hide earlier ones.
+		devNum = fields[2]
+		longest = len(mountPoint)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if devNum == "" {
+		return "", fmt.Errorf("no mount found for %s", path)
+	}
+	return devNum, nil
+}
+
+func isPathUnder(path, dir string) bool {
+	if dir == "/" {
+		return true
+	}
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// unescapeMountPoint decodes the octal escapes (e.g. "\040" for a space) of a
+// mountinfo field.
+func unescapeMountPoint(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pravk03/topologyutil/pkg/internal/sysfstest"
+)
+
+func nvme(path string, numaNode string, files map[string]string) testDevice {
+	device := testDevice{
+		path: path,
+		files: map[string]string{
+			"vendor":     "0x144d\n",
+			"device":     "0xa80a\n",
+			"class":      "0x010802\n",
+			"numa_node":  numaNode + "\n",
+			"local_cpus": "00000000,ffff0000\n",
+		},
+		links: map[string]string{"driver": "bus/pci/drivers/nvme"},
+	}
+	for name, data := range files {
+		device.files[name] = data
+	}
+	return device
+}
+
+// testBlockHostRoot is a machine with an NVMe drive, a multipath NVMe drive
+// reached through two controllers, a SATA disk and a device mapper volume
+// striped over the NVMe drive and the SATA disk.
+func testBlockHostRoot(t *testing.T) string {
+	const (
+		nvme0 = "devices/pci0000:00/0000:00:01.0/0000:01:00.0"
+		nvme1 = "devices/pci0000:80/0000:80:01.0/0000:81:00.0"
+		nvme2 = "devices/pci0000:80/0000:80:02.0/0000:82:00.0"
+		ahci  = "devices/pci0000:00/0000:00:17.0"
+		sda   = ahci + "/ata1/host0/target0:0:0/0:0:0:0/block/sda"
+	)
+	hostRoot := newTestHostRoot(t, []testDevice{
+		nvme("pci0000:00/0000:00:01.0/0000:01:00.0", "0", map[string]string{
+			"nvme/nvme0/model":                  "SAMSUNG MZQL23T8HCLS-00A07   \n",
+			"nvme/nvme0/serial":                 "S64HNE0T\n",
+			"nvme/nvme0/firmware_rev":           "GDC5602Q\n",
+			"nvme/nvme0/nvme0n1/size":           "7501476528\n",
+			"nvme/nvme0/nvme0n1/nvme0n1p1/size": "2048\n",
+			"nvme/nvme0/nvme0n1/nvme0n1p2/size": "7501472432\n",
+		}),
+		nvme("pci0000:80/0000:80:01.0/0000:81:00.0", "1", map[string]string{
+			"nvme/nvme1/nvme1c1n1/size": "2048\n",
+		}),
+		nvme("pci0000:80/0000:80:02.0/0000:82:00.0", "1", map[string]string{
+			"nvme/nvme2/nvme1c2n1/size": "2048\n",
+		}),
+		{
+			path: "pci0000:00/0000:00:17.0",
+			files: map[string]string{
+				"vendor": "0x8086\n",
+				"device": "0x7ae2\n",
+				"class":  "0x010601\n",
+			},
+			links: map[string]string{"driver": "bus/pci/drivers/ahci"},
+		},
+	})
+
+	sysfstest.WriteFile(t, hostRoot, "sys/"+sda+"/sda1/size", "2048\n")
+	sysfstest.WriteFile(t, hostRoot, "sys/devices/virtual/block/dm-0/size", "2048\n")
+	sysfstest.WriteFile(t, hostRoot, "sys/devices/virtual/nvme-subsystem/nvme-subsys1/nvme1n1/size", "2048\n")
+	for name, target := range map[string]string{
+		"nvme0n1":   nvme0 + "/nvme/nvme0/nvme0n1",
+		"nvme0n1p2": nvme0 + "/nvme/nvme0/nvme0n1/nvme0n1p2",
+		"nvme1n1":   "devices/virtual/nvme-subsystem/nvme-subsys1/nvme1n1",
+		"sda":       sda,
+		"sda1":      sda + "/sda1",
+		"dm-0":      "devices/virtual/block/dm-0",
+	} {
+		sysfstest.Symlink(t, hostRoot, "sys/"+target, "sys/class/block/"+name)
+	}
+	for _, name := range []string{"nvme0n1", "nvme0n1p2", "sda", "sda1", "dm-0"} {
+		sysfstest.WriteFile(t, hostRoot, "dev/"+name, "")
+	}
+	// udev links to the kernel names are relative.
+	for name, target := range map[string]string{
+		"dev/mapper/vg-data":                         "../dm-0",
+		"dev/disk/by-id/nvme-SAMSUNG_S64HNE0T-part2": "../../nvme0n1p2",
+	} {
+		if err := os.MkdirAll(filepath.Join(hostRoot, filepath.Dir(name)), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.Symlink(target, filepath.Join(hostRoot, name)); err != nil {
+			t.Fatalf("Symlink() error = %v", err)
+		}
+	}
+	sysfstest.Symlink(t, hostRoot, "sys/class/block/nvme0n1p2", "sys/devices/virtual/block/dm-0/slaves/nvme0n1p2")
+	sysfstest.Symlink(t, hostRoot, "sys/class/block/sda1", "sys/devices/virtual/block/dm-0/slaves/sda1")
+	sysfstest.Symlink(t, hostRoot, "sys/class/block/sda1", "sys/dev/block/8:1")
+	sysfstest.Symlink(t, hostRoot, "sys/class/block/dm-0", "sys/dev/block/253:0")
+	sysfstest.Symlink(t, hostRoot, "sys/class/block/nvme0n1p2", "sys/dev/block/259:2")
+	sysfstest.WriteFile(t, hostRoot, "proc/self/mountinfo", `1 0 8:1 / / rw,relatime - ext4 /dev/sda1 rw
+24 1 0:22 / /proc rw,nosuid - proc proc rw
+30 1 259:2 / /scratch rw,relatime - xfs /dev/nvme0n1p2 rw
+31 1 253:0 / /data\040set rw,relatime - xfs /dev/mapper/vg-data rw
+32 30 0:40 / /scratch/tmp rw,relatime - tmpfs tmpfs rw
+`)
+	return hostRoot
+}
+
+func TestNVMeController(t *testing.T) {
+	testBlockHostRoot(t)
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	device, ok := pcieInfo.FindDeviceByAddress("0000:01:00.0")
+	if !ok {
+		t.Fatalf("FindDeviceByAddress() found = false, want true")
+	}
+	want := &NVMeController{
+		Name:     "nvme0",
+		Model:    "SAMSUNG MZQL23T8HCLS-00A07",
+		Serial:   "S64HNE0T",
+		Firmware: "GDC5602Q",
+		Namespaces: []NVMeNamespace{
+			{
+				Name:        "nvme0n1",
+				BlockDevice: "nvme0n1",
+				SizeBytes:   7501476528 * 512,
+				Partitions:  []string{"nvme0n1p1", "nvme0n1p2"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(device.NVMe, want) {
+		t.Errorf("NVMe = %+v, want %+v", device.NVMe, want)
+	}
+
+	device, _ = pcieInfo.FindDeviceByAddress("0000:82:00.0")
+	wantNamespaces := []NVMeNamespace{{Name: "nvme1c2n1", BlockDevice: "nvme1n1", SizeBytes: 2048 * 512}}
+	if device.NVMe == nil || !reflect.DeepEqual(device.NVMe.Namespaces, wantNamespaces) {
+		t.Errorf("NVMe = %+v, want namespaces %+v", device.NVMe, wantNamespaces)
+	}
+
+	device, _ = pcieInfo.FindDeviceByAddress("0000:00:17.0")
+	if device.NVMe != nil {
+		t.Errorf("NVMe = %+v, want nil", device.NVMe)
+	}
+}
+
+func TestFindDevicesByBlockDevice(t *testing.T) {
+	testBlockHostRoot(t)
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "nvme0n1", want: []string{"0000:01:00.0"}},
+		{name: "/dev/nvme0n1p2", want: []string{"0000:01:00.0"}},
+		{name: "nvme1n1", want: []string{"0000:81:00.0", "0000:82:00.0"}},
+		{name: "sda", want: []string{"0000:00:17.0"}},
+		{name: "sda1", want: []string{"0000:00:17.0"}},
+		{name: "dm-0", want: []string{"0000:00:17.0", "0000:01:00.0"}},
+		{name: "/dev/mapper/vg-data", want: []string{"0000:00:17.0", "0000:01:00.0"}},
+		{name: "/dev/disk/by-id/nvme-SAMSUNG_S64HNE0T-part2", want: []string{"0000:01:00.0"}},
+		{name: "/dev/mapper/missing", wantErr: true},
+		{name: "loop0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices, err := pcieInfo.FindDevicesByBlockDevice(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindDevicesByBlockDevice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := []string{}
+			for _, device := range devices {
+				got = append(got, device.Address)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDevicesByBlockDevice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDevicesByPath(t *testing.T) {
+	testBlockHostRoot(t)
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "/", want: []string{"0000:00:17.0"}},
+		{path: "/scratch", want: []string{"0000:01:00.0"}},
+		{path: "/scratch/job/input", want: []string{"0000:01:00.0"}},
+		{path: "/scratchpad", want: []string{"0000:00:17.0"}},
+		{path: "/data set/file", want: []string{"0000:00:17.0", "0000:01:00.0"}},
+		{path: "/scratch/tmp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			devices, err := pcieInfo.FindDevicesByPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindDevicesByPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := []string{}
+			for _, device := range devices {
+				got = append(got, device.Address)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDevicesByPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
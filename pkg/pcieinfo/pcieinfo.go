@@ -2,11 +2,19 @@ package pcieinfo
 
 import (
 	"fmt"
+	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
 	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/pciids"
 )
 
 // PCIEDeviceInfo holds information about a single PCIe device.
@@ -19,10 +27,72 @@ type PCIEDeviceInfo struct {
 	Class                string `json:"class"`
 	Driver               string `json:"driver"`
 	PCIERootComplexID    string `json:"pcieRootComplexId"`
-	NUMANode             int    `json:"numaNode"`
+	NUMANode             int    `json:"numaNode"`                   // UnknownNUMANode when unknown
+	NUMANodeInferred     bool   `json:"numaNodeInferred,omitempty"` // NUMANode was inferred from local_cpus
 	NumaNodeAffinityMask string `json:"numaNodeAffinityMask"`
+	IOMMUGroup           string `json:"iommuGroup,omitempty"`
+
+	// IOMMUGroupDevices are the addresses of every member of the IOMMU group,
+	// including the device itself.
+	IOMMUGroupDevices []string `json:"iommuGroupDevices,omitempty"`
+
+	// ACS is nil when the device has no visible ACS capability.
+	ACS *ACS `json:"acs,omitempty"`
+
+	// ParentAddress is the address of the upstream bridge, empty when the
+	// device sits directly on its root complex.
+	ParentAddress string `json:"parentAddress,omitempty"`
+
+	// Link is nil when the device does not report its PCIe link.
+	Link *PCIELink `json:"link,omitempty"`
+
+	// NetInterfaces are the network interfaces of the device, ordered by name.
+	NetInterfaces []NetInterface `json:"netInterfaces,omitempty"`
+
+	// RDMADevices are the RDMA devices of the device (e.g. "mlx5_0").
+	RDMADevices []string `json:"rdmaDevices,omitempty"`
+
+	// NVMe is nil for devices that are not NVMe controllers.
+	NVMe *NVMeController `json:"nvme,omitempty"`
+
+	// SRIOV is nil for devices that are neither SR-IOV physical nor virtual
+	// functions.
+	SRIOV *SRIOV `json:"sriov,omitempty"`
+
+	// BARs are the assigned memory and I/O regions of the device.
+	BARs []BAR `json:"bars,omitempty"`
+
+	// Health is nil when the device reports neither its power state nor
+	// whether it is enabled.
+	Health *Health `json:"health,omitempty"`
+
+	// Names are resolved from pci.ids; nil when nothing is known.
+	Names *pciids.Names `json:"names,omitempty"`
+}
+
+// LocalCPUs returns the machine CPUs local to the device, decoded from its
+// `local_cpus` mask.
+func (d PCIEDeviceInfo) LocalCPUs() (cpuset.CPUSet, error) {
+	mask, err := bitmaputil.NewFrom(d.NumaNodeAffinityMask)
+	if err != nil {
+		return cpuset.New(), fmt.Errorf("invalid local CPU mask %q for device %s: %w", d.NumaNodeAffinityMask, d.Address, err)
+	}
+	return cpuset.New(bitmaputil.List(mask)...), nil
+}
+
+// ClassCode decodes the class of the device into base class, subclass and
+// programming interface.
+func (d PCIEDeviceInfo) ClassCode() (pciids.ClassCode, error) {
+	return pciids.ParseClassCode(d.Class)
 }
 
+// UnknownNUMANode is the NUMANode of a device whose NUMA node is unknown, as
+// reported by the kernel on single node or firmware deficient systems.
+const UnknownNUMANode = -1
+
+// pciAddressRegexp matches a PCI address (domain:bus:device.function).
+var pciAddressRegexp = regexp.MustCompile(`^[0-9a-f]{4,}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-7]$`)
+
 // PCIEDeviceKey defines a unique key for a PCIe device based on its IDs.
 // This struct will be used as the key in the map.
 type PCIEDeviceKey struct {
@@ -35,15 +105,48 @@ type PCIEDeviceKey struct {
 // PCIEInfo is a struct that holds the collection of all PCIe devices.
 type PCIEInfo struct {
 	Devices map[PCIEDeviceKey]PCIEDeviceInfo
+
+	// byAddress holds every device, including those sharing the same IDs
+	// (e.g. several identical GPUs), keyed by PCI address.
+	byAddress map[string]PCIEDeviceInfo
+
+	// ids is kept to name the devices read again by Refresh.
+	ids *pciids.Database
+}
+
+// NewPCIEInfoFromDevices returns a PCIEInfo holding the given devices.
+func NewPCIEInfoFromDevices(devices []PCIEDeviceInfo) *PCIEInfo {
+	p := &PCIEInfo{
+		Devices:   make(map[PCIEDeviceKey]PCIEDeviceInfo, len(devices)),
+		byAddress: make(map[string]PCIEDeviceInfo, len(devices)),
+	}
+	for _, deviceInfo := range devices {
+		p.add(deviceInfo)
+	}
+	return p
+}
+
+func (p *PCIEInfo) add(deviceInfo PCIEDeviceInfo) {
+	key := PCIEDeviceKey{
+		VendorID:    deviceInfo.VendorID,
+		DeviceID:    deviceInfo.DeviceID,
+		SubVendorID: deviceInfo.SubVendorID,
+		SubDeviceID: deviceInfo.SubDeviceID,
+	}
+	p.Devices[key] = deviceInfo
+	p.byAddress[deviceInfo.Address] = deviceInfo
 }
 
 // NewPCIEInfo scans the system and returns a new PCIEInfo instance
 // containing a map of all found PCIe devices.
 func NewPCIEInfo() (*PCIEInfo, error) {
-	devices := make(map[PCIEDeviceKey]PCIEDeviceInfo)
+	devices := []PCIEDeviceInfo{}
 	pciPath := cpuinfo.HostSys("bus/pci/devices")
 
-	fmt.Printf("Reading PCIe devices from: %s\n", pciPath)
+	log.Printf("Reading PCIe devices from: %s", pciPath)
+
+	ids := loadPCIIDs()
+	nodeCpus := readNodeCPUs()
 
 	err := filepath.Walk(pciPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -51,64 +154,174 @@ func NewPCIEInfo() (*PCIEInfo, error) {
 		}
 
 		if info.Mode()&os.ModeSymlink != 0 && path != pciPath {
-			addr := filepath.Base(path)
-			realDevPath, err := filepath.EvalSymlinks(path)
+			deviceInfo, err := readDevice(path, ids, nodeCpus)
 			if err != nil {
 				return nil
 			}
-
-			vendor, _ := readFile(filepath.Join(path, "vendor"))
-			device, _ := readFile(filepath.Join(path, "device"))
-			subvendor, _ := readFile(filepath.Join(path, "subsystem_vendor"))
-			subdevice, _ := readFile(filepath.Join(path, "subsystem_device"))
-
-			// Create the unique key for this device, trimming the "0x" prefix for the key.
-			key := PCIEDeviceKey{
-				VendorID:    strings.TrimPrefix(strings.TrimSpace(vendor), "0x"),
-				DeviceID:    strings.TrimPrefix(strings.TrimSpace(device), "0x"),
-				SubVendorID: strings.TrimPrefix(strings.TrimSpace(subvendor), "0x"),
-				SubDeviceID: strings.TrimPrefix(strings.TrimSpace(subdevice), "0x"),
-			}
-
-			class, _ := readFile(filepath.Join(path, "class"))
-			driver, _ := readLink(filepath.Join(path, "driver"))
-			numaNode, _ := readIntFromFile(filepath.Join(path, "numa_node"))
-			numaNodeAffinityMask, _ := readFile(filepath.Join(path, "local_cpus"))
-
-			pcieRootComplexID := addr
-			tempDevPath := realDevPath
-			for {
-				parentPath := filepath.Dir(tempDevPath)
-				parentBase := filepath.Base(parentPath)
-				if filepath.Base(filepath.Dir(parentPath)) == "devices" {
-					pcieRootComplexID = parentBase
-					break
-				}
-				tempDevPath = parentPath
-			}
-
-			devices[key] = PCIEDeviceInfo{
-				Address:              addr,
-				VendorID:             key.VendorID,
-				DeviceID:             key.DeviceID,
-				SubVendorID:          key.SubVendorID,
-				SubDeviceID:          key.SubDeviceID,
-				Class:                strings.TrimSpace(class),
-				Driver:               driver,
-				NUMANode:             numaNode,
-				PCIERootComplexID:    pcieRootComplexID,
-				NumaNodeAffinityMask: formatAffinityMask(numaNodeAffinityMask),
-			}
+			devices = append(devices, deviceInfo)
 		}
 		return nil
 	})
 
 	if err != nil && !os.IsNotExist(err) {
-		fmt.Printf("Error walking PCIe devices: %v\n", err)
+		log.Printf("Error walking PCIe devices: %v", err)
 		return nil, err
 	}
 
-	return &PCIEInfo{Devices: devices}, nil
+	p := NewPCIEInfoFromDevices(devices)
+	p.ids = ids
+	return p, nil
+}
+
+// loadPCIIDs loads the host pci.ids, falling back to the built-in class
+// names.
+func loadPCIIDs() *pciids.Database {
+	ids, err := pciids.Load()
+	if err != nil {
+		log.Printf("Warning: failed to load pci.ids: %v", err)
+		ids, _ = pciids.Parse(strings.NewReader(""))
+	}
+	return ids
+}
+
+// readDevice reads the device at path, a link in /sys/bus/pci/devices.
+func readDevice(path string, ids *pciids.Database, nodeCpus map[int]cpuset.CPUSet) (PCIEDeviceInfo, error) {
+	addr := filepath.Base(path)
+	realDevPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return PCIEDeviceInfo{}, err
+	}
+
+	vendor, _ := readFile(filepath.Join(path, "vendor"))
+	device, _ := readFile(filepath.Join(path, "device"))
+	subvendor, _ := readFile(filepath.Join(path, "subsystem_vendor"))
+	subdevice, _ := readFile(filepath.Join(path, "subsystem_device"))
+
+	// Create the unique key for this device, trimming the "0x" prefix for the key.
+	key := PCIEDeviceKey{
+		VendorID:    strings.TrimPrefix(strings.TrimSpace(vendor), "0x"),
+		DeviceID:    strings.TrimPrefix(strings.TrimSpace(device), "0x"),
+		SubVendorID: strings.TrimPrefix(strings.TrimSpace(subvendor), "0x"),
+		SubDeviceID: strings.TrimPrefix(strings.TrimSpace(subdevice), "0x"),
+	}
+
+	class, _ := readFile(filepath.Join(path, "class"))
+	driver, _ := readLink(filepath.Join(path, "driver"))
+	numaNodeAffinityMask, _ := readFile(filepath.Join(path, "local_cpus"))
+	numaNode, err := readIntFromFile(filepath.Join(path, "numa_node"))
+	if err != nil || numaNode < 0 {
+		numaNode = UnknownNUMANode
+	}
+	numaNodeInferred := false
+	if numaNode == UnknownNUMANode {
+		numaNode, numaNodeInferred = inferNUMANode(formatAffinityMask(numaNodeAffinityMask), nodeCpus)
+	}
+	iommuGroup, _ := readLink(filepath.Join(path, "iommu_group"))
+	rdmaDevices := readRDMADevices(path)
+
+	// Walk up to the root complex, remembering the nearest upstream
+	// PCI device (the bridge the device sits behind).
+	pcieRootComplexID := addr
+	parentAddress := ""
+	tempDevPath := realDevPath
+	for {
+		parentPath := filepath.Dir(tempDevPath)
+		parentBase := filepath.Base(parentPath)
+		if parentPath == tempDevPath {
+			break
+		}
+		if filepath.Base(filepath.Dir(parentPath)) == "devices" {
+			pcieRootComplexID = parentBase
+			break
+		}
+		if parentAddress == "" && pciAddressRegexp.MatchString(parentBase) {
+			parentAddress = parentBase
+		}
+		tempDevPath = parentPath
+	}
+
+	names := ids.Lookup(key.VendorID, key.DeviceID, key.SubVendorID, key.SubDeviceID, class)
+	var namesPtr *pciids.Names
+	if names != (pciids.Names{}) {
+		namesPtr = &names
+	}
+
+	return PCIEDeviceInfo{
+		Address:              addr,
+		VendorID:             key.VendorID,
+		DeviceID:             key.DeviceID,
+		SubVendorID:          key.SubVendorID,
+		SubDeviceID:          key.SubDeviceID,
+		Class:                strings.TrimSpace(class),
+		Driver:               driver,
+		NUMANode:             numaNode,
+		NUMANodeInferred:     numaNodeInferred,
+		PCIERootComplexID:    pcieRootComplexID,
+		NumaNodeAffinityMask: formatAffinityMask(numaNodeAffinityMask),
+		IOMMUGroup:           iommuGroup,
+		IOMMUGroupDevices:    readIOMMUGroupDevices(iommuGroup),
+		ACS:                  readACS(path),
+		ParentAddress:        parentAddress,
+		Link:                 readPCIELink(path),
+		NetInterfaces:        readNetInterfaces(path, rdmaDevices),
+		RDMADevices:          rdmaDevices,
+		NVMe:                 readNVMeController(path),
+		SRIOV:                readSRIOV(path),
+		BARs:                 readBARs(path),
+		Health:               readHealth(path),
+		Names:                namesPtr,
+	}, nil
+}
+
+// Refresh returns a copy of the inventory with the devices at the addresses
+// read again from sysfs, such as after a hotplug event. Devices that are gone
+// are dropped. The SR-IOV physical functions and IOMMU group members of the
+// devices are refreshed as well, as their view of the devices changes too.
+func (p *PCIEInfo) Refresh(addresses ...string) *PCIEInfo {
+	ids := p.ids
+	if ids == nil {
+		ids = loadPCIIDs()
+	}
+	nodeCpus := readNodeCPUs()
+
+	devices := make(map[string]PCIEDeviceInfo, len(p.byAddress))
+	for address, deviceInfo := range p.byAddress {
+		devices[address] = deviceInfo
+	}
+	refreshed := map[string]bool{}
+	refresh := func(address string) {
+		if refreshed[address] {
+			return
+		}
+		refreshed[address] = true
+		deviceInfo, err := readDevice(cpuinfo.HostSys("bus/pci/devices", address), ids, nodeCpus)
+		if err != nil {
+			delete(devices, address)
+			return
+		}
+		devices[address] = deviceInfo
+	}
+
+	for _, address := range addresses {
+		old := devices[address]
+		refresh(address)
+		for _, deviceInfo := range []PCIEDeviceInfo{old, devices[address]} {
+			if deviceInfo.SRIOV != nil && deviceInfo.SRIOV.PhysFn != "" {
+				refresh(deviceInfo.SRIOV.PhysFn)
+			}
+			for _, member := range deviceInfo.IOMMUGroupDevices {
+				refresh(member)
+			}
+		}
+	}
+
+	// Add the devices in address order, like NewPCIEInfo, so devices sharing
+	// the same IDs resolve the same way.
+	refreshedInfo := NewPCIEInfoFromDevices(slices.SortedFunc(maps.Values(devices), func(a, b PCIEDeviceInfo) int {
+		return strings.Compare(a.Address, b.Address)
+	}))
+	refreshedInfo.ids = ids
+	return refreshedInfo
 }
 
 // FindDevice is now a METHOD on the PCIEInfo struct.
@@ -124,13 +337,22 @@ func (p *PCIEInfo) FindDevice(vendor, device, subVendor, subDevice string) (PCIE
 	return deviceInfo, found
 }
 
-// GetAllDevices returns a slice of all found PCIEDeviceInfo objects.
-// This is useful for iterating over all devices.
+// FindDeviceByAddress looks up a device by its PCI address (e.g. "0000:3b:00.0").
+func (p *PCIEInfo) FindDeviceByAddress(address string) (PCIEDeviceInfo, bool) {
+	deviceInfo, found := p.byAddress[address]
+	return deviceInfo, found
+}
+
+// GetAllDevices returns a slice of all found PCIEDeviceInfo objects, ordered
+// by address. This is useful for iterating over all devices.
 func (p *PCIEInfo) GetAllDevices() []PCIEDeviceInfo {
-	allDevices := make([]PCIEDeviceInfo, 0, len(p.Devices))
-	for _, deviceInfo := range p.Devices {
+	allDevices := make([]PCIEDeviceInfo, 0, len(p.byAddress))
+	for _, deviceInfo := range p.byAddress {
 		allDevices = append(allDevices, deviceInfo)
 	}
+	slices.SortFunc(allDevices, func(a, b PCIEDeviceInfo) int {
+		return strings.Compare(a.Address, b.Address)
+	})
 	return allDevices
 }
 
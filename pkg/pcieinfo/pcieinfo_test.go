@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pravk03/topologyutil/pkg/internal/sysfstest"
+	"github.com/pravk03/topologyutil/pkg/pciids"
+)
+
+// testDevice is a PCI device of a fake sysfs tree.
+type testDevice struct {
+	// path is relative to /sys/devices (e.g. "pci0000:00/0000:00:01.0").
+	path string
+	// files are the sysfs attributes of the device.
+	files map[string]string
+	// links are the symbolic links of the device, relative to /sys.
+	links map[string]string
+}
+
+// newTestHostRoot builds a fake sysfs tree holding the devices and points
+// HOST_ROOT at it.
+func newTestHostRoot(t *testing.T, devices []testDevice) string {
+	t.Helper()
+	hostRoot := sysfstest.NewHostRoot(t)
+	for _, device := range devices {
+		sysfstest.AddDevice(t, hostRoot, sysfstest.Device{Path: device.path, Files: device.files, Links: device.links})
+	}
+	return hostRoot
+}
+
+func bridge(path string) testDevice {
+	return testDevice{
+		path: path,
+		files: map[string]string{
+			"vendor": "0x8086\n",
+			"device": "0x1234\n",
+			"class":  "0x060400\n",
+		},
+		links: map[string]string{
+			"driver": "bus/pci/drivers/pcieport",
+		},
+	}
+}
+
+func gpu(path string, numaNode string) testDevice {
+	return testDevice{
+		path: path,
+		files: map[string]string{
+			"vendor":           "0x10de\n",
+			"device":           "0x20b0\n",
+			"subsystem_vendor": "0x10de\n",
+			"subsystem_device": "0x1463\n",
+			"class":            "0x030200\n",
+			"numa_node":        numaNode + "\n",
+			"local_cpus":       "00000000,0000ffff\n",
+		},
+		links: map[string]string{
+			"driver":      "bus/pci/drivers/nvidia",
+			"iommu_group": "kernel/iommu_groups/" + numaNode,
+		},
+	}
+}
+
+// testDevices is a machine with two identical GPUs behind one PCIe switch
+// and a third on another root complex.
+func testDevices() []testDevice {
+	return []testDevice{
+		bridge("pci0000:00/0000:00:01.0"),
+		bridge("pci0000:00/0000:00:01.0/0000:01:00.0"),
+		bridge("pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:00.0"),
+		bridge("pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:01.0"),
+		gpu("pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:00.0/0000:03:00.0", "0"),
+		gpu("pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:01.0/0000:04:00.0", "0"),
+		bridge("pci0000:80/0000:80:01.0"),
+		gpu("pci0000:80/0000:80:01.0/0000:81:00.0", "1"),
+	}
+}
+
+func TestNewPCIEInfo(t *testing.T) {
+	newTestHostRoot(t, testDevices())
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+	if got := len(pcieInfo.GetAllDevices()); got != 8 {
+		t.Errorf("GetAllDevices() = %d devices, want 8", got)
+	}
+
+	got, ok := pcieInfo.FindDeviceByAddress("0000:04:00.0")
+	if !ok {
+		t.Fatalf("FindDeviceByAddress() found = false, want true")
+	}
+	want := PCIEDeviceInfo{
+		Address:              "0000:04:00.0",
+		VendorID:             "10de",
+		DeviceID:             "20b0",
+		SubVendorID:          "10de",
+		SubDeviceID:          "1463",
+		Class:                "0x030200",
+		Driver:               "nvidia",
+		PCIERootComplexID:    "pci0000:00",
+		NUMANode:             0,
+		NumaNodeAffinityMask: "0x000000000000ffff",
+		IOMMUGroup:           "0",
+		ParentAddress:        "0000:02:01.0",
+		Names: &pciids.Names{
+			Class:    "Display controller",
+			Subclass: "3D controller",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindDeviceByAddress() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	const root = "devices/pci0000:80/0000:80:01.0/"
+	hostRoot := newTestHostRoot(t, []testDevice{
+		bridge("pci0000:80/0000:80:01.0"),
+		nic("pci0000:80/0000:80:01.0/0000:81:00.0",
+			map[string]string{"sriov_totalvfs": "8\n", "sriov_numvfs": "0\n"},
+			map[string]string{"driver": "bus/pci/drivers/mlx5_core"}),
+	})
+	sysPath := filepath.Join(hostRoot, "sys")
+	pfPath := filepath.Join(sysPath, root, "0000:81:00.0")
+	vfPath := filepath.Join(sysPath, root, "0000:81:00.2")
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	// Create a VF, as writing sriov_numvfs does.
+	if err := os.MkdirAll(vfPath, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	for name, data := range map[string]string{
+		filepath.Join(vfPath, "vendor"):       "0x15b3\n",
+		filepath.Join(vfPath, "device"):       "0x101e\n",
+		filepath.Join(vfPath, "class"):        "0x020000\n",
+		filepath.Join(pfPath, "sriov_numvfs"): "1\n",
+	} {
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	for name, target := range map[string]string{
+		filepath.Join(vfPath, "physfn"):                           pfPath,
+		filepath.Join(pfPath, "virtfn0"):                          vfPath,
+		filepath.Join(sysPath, "bus/pci/devices", "0000:81:00.2"): vfPath,
+	} {
+		if err := os.Symlink(target, name); err != nil {
+			t.Fatalf("Symlink() error = %v", err)
+		}
+	}
+
+	refreshed := pcieInfo.Refresh("0000:81:00.2")
+	vf, ok := refreshed.FindDeviceByAddress("0000:81:00.2")
+	if !ok {
+		t.Fatalf("FindDeviceByAddress() found = false, want true")
+	}
+	if vf.DeviceID != "101e" || vf.ParentAddress != "0000:80:01.0" {
+		t.Errorf("FindDeviceByAddress() = %+v, want device 101e behind 0000:80:01.0", vf)
+	}
+	pf, _ := refreshed.FindDeviceByAddress("0000:81:00.0")
+	if want := []string{"0000:81:00.2"}; pf.SRIOV == nil || !reflect.DeepEqual(pf.SRIOV.VFs, want) {
+		t.Errorf("PF SRIOV = %+v, want VFs %v", pf.SRIOV, want)
+	}
+	if _, ok := pcieInfo.FindDeviceByAddress("0000:81:00.2"); ok {
+		t.Errorf("Refresh() modified the original inventory")
+	}
+
+	// Remove the VF again.
+	for _, name := range []string{
+		filepath.Join(sysPath, "bus/pci/devices", "0000:81:00.2"),
+		filepath.Join(pfPath, "virtfn0"),
+	} {
+		if err := os.Remove(name); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+	}
+	refreshed = refreshed.Refresh("0000:81:00.2")
+	if _, ok := refreshed.FindDeviceByAddress("0000:81:00.2"); ok {
+		t.Errorf("FindDeviceByAddress() found = true after removal, want false")
+	}
+	if got := len(refreshed.GetAllDevices()); got != 2 {
+		t.Errorf("GetAllDevices() = %d devices, want 2", got)
+	}
+	pf, _ = refreshed.FindDeviceByAddress("0000:81:00.0")
+	if pf.SRIOV == nil || len(pf.SRIOV.VFs) != 0 {
+		t.Errorf("PF SRIOV = %+v, want no VFs", pf.SRIOV)
+	}
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SRIOV holds the SR-IOV state of a physical or virtual function.
+type SRIOV struct {
+	// TotalVFs and NumVFs are only set on physical functions.
+	TotalVFs int `json:"totalVFs,omitempty"`
+	NumVFs   int `json:"numVFs,omitempty"`
+
+	// VFs are the addresses of the enabled virtual functions, ordered by
+	// VF index.
+	VFs []string `json:"vfs,omitempty"`
+
+	// PhysFn is the address of the physical function of a virtual function.
+	PhysFn string `json:"physFn,omitempty"`
+}
+
+// IsPF returns true for SR-IOV capable physical functions.
+func (s *SRIOV) IsPF() bool {
+	return s != nil && s.TotalVFs > 0
+}
+
+// IsVF returns true for virtual functions.
+func (s *SRIOV) IsVF() bool {
+	return s != nil && s.PhysFn != ""
+}
+
+// readSRIOV reads the SR-IOV attributes of the device, returning nil when the
+// device is neither a physical nor a virtual function.
+func readSRIOV(devicePath string) *SRIOV {
+	sriov := &SRIOV{}
+	if physFn, err := readLink(filepath.Join(devicePath, "physfn")); err == nil {
+		sriov.PhysFn = physFn
+		return sriov
+	}
+
+	totalVFs, err := readIntFromFile(filepath.Join(devicePath, "sriov_totalvfs"))
+	if err != nil || totalVFs <= 0 {
+		return nil
+	}
+	sriov.TotalVFs = totalVFs
+	sriov.NumVFs, _ = readIntFromFile(filepath.Join(devicePath, "sriov_numvfs"))
+
+	entries, err := os.ReadDir(devicePath)
+	if err != nil {
+		return sriov
+	}
+	type virtfn struct {
+		index   int
+		address string
+	}
+	virtfns := []virtfn{}
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), "virtfn")
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(suffix)
+		if err != nil {
+			continue
+		}
+		address, err := readLink(filepath.Join(devicePath, entry.Name()))
+		if err != nil {
+			continue
+		}
+		virtfns = append(virtfns, virtfn{index: index, address: address})
+	}
+	slices.SortFunc(virtfns, func(a, b virtfn) int {
+		return a.index - b.index
+	})
+	for _, vf := range virtfns {
+		sriov.VFs = append(sriov.VFs, vf.address)
+	}
+	return sriov
+}
+
+// SRIOVTree is a physical function and its virtual functions.
+type SRIOVTree struct {
+	PF  PCIEDeviceInfo   `json:"pf"`
+	VFs []PCIEDeviceInfo `json:"vfs"`
+}
+
+// SRIOVTrees returns the SR-IOV physical functions with their enabled
+// virtual functions, ordered by address.
+func (p *PCIEInfo) SRIOVTrees() []SRIOVTree {
+	trees := []SRIOVTree{}
+	for _, device := range p.byAddress {
+		if !device.SRIOV.IsPF() {
+			continue
+		}
+		tree := SRIOVTree{
+			PF:  device,
+			VFs: []PCIEDeviceInfo{},
+		}
+		for _, address := range device.SRIOV.VFs {
+			if vf, ok := p.byAddress[address]; ok {
+				tree.VFs = append(tree.VFs, vf)
+			}
+		}
+		trees = append(trees, tree)
+	}
+	slices.SortFunc(trees, func(a, b SRIOVTree) int {
+		return strings.Compare(a.PF.Address, b.PF.Address)
+	})
+	return trees
+}
+
+// VirtualFunctions returns the enabled virtual functions of the physical
+// function, ordered by VF index.
+func (p *PCIEInfo) VirtualFunctions(pfAddress string) []PCIEDeviceInfo {
+	vfs := []PCIEDeviceInfo{}
+	pf, ok := p.byAddress[pfAddress]
+	if !ok || !pf.SRIOV.IsPF() {
+		return vfs
+	}
+	for _, address := range pf.SRIOV.VFs {
+		if vf, ok := p.byAddress[address]; ok {
+			vfs = append(vfs, vf)
+		}
+	}
+	return vfs
+}
+
+func (t SRIOVTree) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s:%s numa=%d vfs=%d/%d", t.PF.Address, t.PF.VendorID, t.PF.DeviceID,
+		t.PF.NUMANode, t.PF.SRIOV.NumVFs, t.PF.SRIOV.TotalVFs)
+	if t.PF.Driver != "" {
+		fmt.Fprintf(&b, " %s", t.PF.Driver)
+	}
+	b.WriteString("\n")
+	for i, vf := range t.VFs {
+		prefix := "├── "
+		if i == len(t.VFs)-1 {
+			prefix = "└── "
+		}
+		driver := vf.Driver
+		if driver == "" {
+			driver = "(unbound)"
+		}
+		fmt.Fprintf(&b, "%s%s numa=%d %s\n", prefix, vf.Address, vf.NUMANode, driver)
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func nic(path string, files map[string]string, links map[string]string) testDevice {
+	device := testDevice{
+		path: path,
+		files: map[string]string{
+			"vendor":    "0x15b3\n",
+			"device":    "0x101d\n",
+			"class":     "0x020000\n",
+			"numa_node": "1\n",
+		},
+		links: map[string]string{},
+	}
+	for name, data := range files {
+		device.files[name] = data
+	}
+	for name, target := range links {
+		device.links[name] = target
+	}
+	return device
+}
+
+func TestSRIOVTrees(t *testing.T) {
+	const root = "devices/pci0000:80/0000:80:01.0/"
+	newTestHostRoot(t, []testDevice{
+		bridge("pci0000:80/0000:80:01.0"),
+		nic("pci0000:80/0000:80:01.0/0000:81:00.0",
+			map[string]string{"sriov_totalvfs": "8\n", "sriov_numvfs": "2\n"},
+			map[string]string{
+				"driver":  "bus/pci/drivers/mlx5_core",
+				"virtfn0": root + "0000:81:00.2",
+				"virtfn1": root + "0000:81:00.3",
+			}),
+		nic("pci0000:80/0000:80:01.0/0000:81:00.2", nil,
+			map[string]string{
+				"driver": "bus/pci/drivers/vfio-pci",
+				"physfn": root + "0000:81:00.0",
+			}),
+		nic("pci0000:80/0000:80:01.0/0000:81:00.3", nil,
+			map[string]string{
+				"physfn": root + "0000:81:00.0",
+			}),
+		// An SR-IOV capable function without any VFs enabled.
+		nic("pci0000:80/0000:80:01.0/0000:81:00.1",
+			map[string]string{"sriov_totalvfs": "8\n", "sriov_numvfs": "0\n"}, nil),
+	})
+
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+
+	trees := pcieInfo.SRIOVTrees()
+	if len(trees) != 2 {
+		t.Fatalf("SRIOVTrees() = %d trees, want 2", len(trees))
+	}
+	wantSRIOV := &SRIOV{
+		TotalVFs: 8,
+		NumVFs:   2,
+		VFs:      []string{"0000:81:00.2", "0000:81:00.3"},
+	}
+	if !reflect.DeepEqual(trees[0].PF.SRIOV, wantSRIOV) {
+		t.Errorf("SRIOVTrees()[0].PF.SRIOV = %+v, want %+v", trees[0].PF.SRIOV, wantSRIOV)
+	}
+	if len(trees[1].VFs) != 0 {
+		t.Errorf("SRIOVTrees()[1].VFs = %v, want none", trees[1].VFs)
+	}
+
+	want := `0000:81:00.0 15b3:101d numa=1 vfs=2/8 mlx5_core
+├── 0000:81:00.2 numa=1 vfio-pci
+└── 0000:81:00.3 numa=1 (unbound)
+`
+	if got := trees[0].String(); got != want {
+		t.Errorf("SRIOVTree.String() = \n%v, want \n%v", got, want)
+	}
+
+	vfs := pcieInfo.VirtualFunctions("0000:81:00.0")
+	if len(vfs) != 2 || !vfs[0].SRIOV.IsVF() || vfs[0].SRIOV.PhysFn != "0000:81:00.0" {
+		t.Errorf("VirtualFunctions() = %+v, want 2 VFs of 0000:81:00.0", vfs)
+	}
+	if got := pcieInfo.VirtualFunctions("0000:81:00.2"); len(got) != 0 {
+		t.Errorf("VirtualFunctions() of a VF = %v, want none", got)
+	}
+}
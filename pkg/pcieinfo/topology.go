@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NodeType is the role of a node in the PCIe hierarchy.
+type NodeType string
+
+const (
+	RootComplex          NodeType = "RootComplex"
+	RootPort             NodeType = "RootPort"
+	SwitchUpstreamPort   NodeType = "SwitchUpstreamPort"
+	SwitchDownstreamPort NodeType = "SwitchDownstreamPort"
+	Bridge               NodeType = "Bridge"
+	Endpoint             NodeType = "Endpoint"
+)
+
+// TopologyNode is a root complex, bridge port or endpoint of the PCIe
+// hierarchy.
+type TopologyNode struct {
+	// ID is the PCI address, or the root complex ID for root complexes.
+	ID   string   `json:"id"`
+	Type NodeType `json:"type"`
+
+	// Device is nil for root complexes.
+	Device   *PCIEDeviceInfo `json:"device,omitempty"`
+	Children []*TopologyNode `json:"children,omitempty"`
+	Parent   *TopologyNode   `json:"-"`
+}
+
+// Topology is the PCIe hierarchy of the machine.
+type Topology struct {
+	// Roots are the root complexes, ordered by ID.
+	Roots []*TopologyNode `json:"roots"`
+
+	nodes map[string]*TopologyNode
+}
+
+// NewTopology builds the PCIe hierarchy of the devices.
+//
+// Port types are inferred from the position of PCI-to-PCI bridges: a bridge on
+// a root complex is a root port, a bridge below a root or downstream port is a
+// switch upstream port, and a bridge below an upstream port is a switch
+// downstream port.
+func (p *PCIEInfo) NewTopology() *Topology {
+	t := &Topology{
+		Roots: []*TopologyNode{},
+		nodes: make(map[string]*TopologyNode, len(p.byAddress)),
+	}
+	devices := p.GetAllDevices()
+	for _, device := range devices {
+		t.nodes[device.Address] = &TopologyNode{
+			ID:     device.Address,
+			Type:   Endpoint,
+			Device: &device,
+		}
+	}
+
+	roots := make(map[string]*TopologyNode)
+	for _, device := range devices {
+		node := t.nodes[device.Address]
+		parent, ok := t.nodes[device.ParentAddress]
+		if !ok {
+			parent, ok = roots[device.PCIERootComplexID]
+			if !ok {
+				parent = &TopologyNode{
+					ID:   device.PCIERootComplexID,
+					Type: RootComplex,
+				}
+				roots[parent.ID] = parent
+				t.Roots = append(t.Roots, parent)
+			}
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+	slices.SortFunc(t.Roots, func(a, b *TopologyNode) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	// Types depend on the parent, so assign them top-down.
+	for _, root := range t.Roots {
+		for _, child := range root.Children {
+			child.assignTypes()
+		}
+	}
+	return t
+}
+
+func (n *TopologyNode) assignTypes() {
+	if isBridgeClass(n.Device.Class) {
+		switch n.Parent.Type {
+		case RootComplex:
+			n.Type = RootPort
+		case RootPort, SwitchDownstreamPort:
+			n.Type = SwitchUpstreamPort
+		case SwitchUpstreamPort:
+			n.Type = SwitchDownstreamPort
+		default:
+			n.Type = Bridge
+		}
+	}
+	for _, child := range n.Children {
+		child.assignTypes()
+	}
+}
+
+// isBridgeClass returns true for PCI-to-PCI bridges.
+func isBridgeClass(class string) bool {
+	class = strings.TrimPrefix(class, "0x")
+	return strings.HasPrefix(class, "0604") || strings.HasPrefix(class, "0609")
+}
+
+// Find returns the node of the device with the PCI address.
+func (t *Topology) Find(address string) (*TopologyNode, bool) {
+	node, ok := t.nodes[address]
+	return node, ok
+}
+
+// Path returns the nodes from the root complex down to the device.
+func (t *Topology) Path(address string) []*TopologyNode {
+	path := []*TopologyNode{}
+	for node, ok := t.nodes[address]; ok && node != nil; node = node.Parent {
+		path = append(path, node)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// CommonAncestor returns the deepest node above both devices, or false when
+// they are on different root complexes.
+func (t *Topology) CommonAncestor(a, b string) (*TopologyNode, bool) {
+	pathA := t.Path(a)
+	pathB := t.Path(b)
+	var common *TopologyNode
+	for i := 0; i < len(pathA)-1 && i < len(pathB)-1; i++ {
+		if pathA[i] != pathB[i] {
+			break
+		}
+		common = pathA[i]
+	}
+	return common, common != nil
+}
+
+// CommonSwitch returns the upstream port of the deepest PCIe switch that both
+// devices sit behind.
+func (t *Topology) CommonSwitch(a, b string) (*TopologyNode, bool) {
+	for node, ok := t.CommonAncestor(a, b); ok && node != nil; node = node.Parent {
+		if node.Type == SwitchUpstreamPort {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// Prune removes the devices that neither match the filter nor lead to a
+// device that does, and the root complexes left without devices.
+func (t *Topology) Prune(filter Filter) {
+	roots := []*TopologyNode{}
+	for _, root := range t.Roots {
+		if t.prune(root, filter) {
+			roots = append(roots, root)
+		}
+	}
+	t.Roots = roots
+}
+
+// prune removes the children of the node that are not kept and returns true
+// when the node itself is kept.
+func (t *Topology) prune(n *TopologyNode, filter Filter) bool {
+	children := []*TopologyNode{}
+	for _, child := range n.Children {
+		if t.prune(child, filter) {
+			children = append(children, child)
+		} else {
+			delete(t.nodes, child.ID)
+		}
+	}
+	n.Children = children
+	return len(children) > 0 || (n.Device != nil && filter(*n.Device))
+}
+
+// String renders the hierarchy as a tree, one node per line.
+func (t *Topology) String() string {
+	var b strings.Builder
+	for _, root := range t.Roots {
+		root.render(&b, "", "")
+	}
+	return b.String()
+}
+
+func (n *TopologyNode) render(b *strings.Builder, prefix, childPrefix string) {
+	b.WriteString(prefix)
+	b.WriteString(n.ID)
+	fmt.Fprintf(b, " [%s]", n.Type)
+	if n.Device != nil {
+		fmt.Fprintf(b, " %s:%s %s", n.Device.VendorID, n.Device.DeviceID, n.Device.Class)
+		if n.Device.Names != nil && n.Device.Names.Device != "" {
+			fmt.Fprintf(b, " %q", n.Device.Names.Device)
+		}
+		if n.Device.Driver != "" {
+			fmt.Fprintf(b, " %s", n.Device.Driver)
+		}
+	}
+	b.WriteString("\n")
+	for i, child := range n.Children {
+		if i == len(n.Children)-1 {
+			child.render(b, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			child.render(b, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pcieinfo
+
+import (
+	"testing"
+)
+
+func TestTopology(t *testing.T) {
+	newTestHostRoot(t, testDevices())
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+	topology := pcieInfo.NewTopology()
+
+	want := `pci0000:00 [RootComplex]
+└── 0000:00:01.0 [RootPort] 8086:1234 0x060400 pcieport
+    └── 0000:01:00.0 [SwitchUpstreamPort] 8086:1234 0x060400 pcieport
+        ├── 0000:02:00.0 [SwitchDownstreamPort] 8086:1234 0x060400 pcieport
+        │   └── 0000:03:00.0 [Endpoint] 10de:20b0 0x030200 nvidia
+        └── 0000:02:01.0 [SwitchDownstreamPort] 8086:1234 0x060400 pcieport
+            └── 0000:04:00.0 [Endpoint] 10de:20b0 0x030200 nvidia
+pci0000:80 [RootComplex]
+└── 0000:80:01.0 [RootPort] 8086:1234 0x060400 pcieport
+    └── 0000:81:00.0 [Endpoint] 10de:20b0 0x030200 nvidia
+`
+	if got := topology.String(); got != want {
+		t.Errorf("Topology.String() = \n%v, want \n%v", got, want)
+	}
+
+	tests := []struct {
+		name       string
+		a, b       string
+		wantSwitch string
+		wantOk     bool
+	}{
+		{
+			name:       "same switch",
+			a:          "0000:03:00.0",
+			b:          "0000:04:00.0",
+			wantSwitch: "0000:01:00.0",
+			wantOk:     true,
+		},
+		{
+			name: "different root complex",
+			a:    "0000:03:00.0",
+			b:    "0000:81:00.0",
+		},
+		{
+			name: "unknown device",
+			a:    "0000:03:00.0",
+			b:    "0000:ff:00.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := topology.CommonSwitch(tt.a, tt.b)
+			if ok != tt.wantOk {
+				t.Fatalf("Topology.CommonSwitch() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got.ID != tt.wantSwitch {
+				t.Errorf("Topology.CommonSwitch() = %v, want %v", got.ID, tt.wantSwitch)
+			}
+		})
+	}
+}
+
+func TestTopology_Prune(t *testing.T) {
+	newTestHostRoot(t, testDevices())
+	pcieInfo, err := NewPCIEInfo()
+	if err != nil {
+		t.Fatalf("NewPCIEInfo() error = %v", err)
+	}
+	topology := pcieInfo.NewTopology()
+	topology.Prune(ByAddress("0000:04:00.0"))
+
+	want := `pci0000:00 [RootComplex]
+└── 0000:00:01.0 [RootPort] 8086:1234 0x060400 pcieport
+    └── 0000:01:00.0 [SwitchUpstreamPort] 8086:1234 0x060400 pcieport
+        └── 0000:02:01.0 [SwitchDownstreamPort] 8086:1234 0x060400 pcieport
+            └── 0000:04:00.0 [Endpoint] 10de:20b0 0x030200 nvidia
+`
+	if got := topology.String(); got != want {
+		t.Errorf("Topology.String() = \n%v, want \n%v", got, want)
+	}
+	for _, address := range []string{"0000:03:00.0", "0000:81:00.0"} {
+		if _, ok := topology.Find(address); ok {
+			t.Errorf("Topology.Find(%q) ok = true, want false", address)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pciids
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ClassCode is the decoded 24-bit PCI class code.
+type ClassCode struct {
+	Base   uint8 `json:"base"`
+	Sub    uint8 `json:"sub"`
+	ProgIf uint8 `json:"progIf"`
+}
+
+// ParseClassCode parses a sysfs class code (e.g. "0x030200").
+func ParseClassCode(class string) (ClassCode, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(class), "0x")
+	v, err := strconv.ParseUint(s, 16, 24)
+	if err != nil || len(s) != 6 {
+		return ClassCode{}, fmt.Errorf("invalid class code %q", class)
+	}
+	return ClassCode{
+		Base:   uint8(v >> 16),
+		Sub:    uint8(v >> 8),
+		ProgIf: uint8(v),
+	}, nil
+}
+
+func (c ClassCode) String() string {
+	return fmt.Sprintf("0x%02x%02x%02x", c.Base, c.Sub, c.ProgIf)
+}
+
+type builtinClass struct {
+	name       string
+	subclasses map[uint8]string
+}
+
+// builtinClasses are the base classes and subclasses of the PCI Code and ID
+// Assignment Specification, used when no pci.ids is installed.
+var builtinClasses = map[uint8]builtinClass{
+	0x00: {"Unclassified device", map[uint8]string{0x00: "Non-VGA unclassified device", 0x01: "VGA compatible unclassified device"}},
+	0x01: {"Mass storage controller", map[uint8]string{
+		0x00: "SCSI storage controller", 0x01: "IDE interface", 0x02: "Floppy disk controller",
+		0x03: "IPI bus controller", 0x04: "RAID bus controller", 0x05: "ATA controller",
+		0x06: "SATA controller", 0x07: "Serial Attached SCSI controller",
+		0x08: "Non-Volatile memory controller", 0x80: "Mass storage controller",
+	}},
+	0x02: {"Network controller", map[uint8]string{
+		0x00: "Ethernet controller", 0x01: "Token ring network controller", 0x02: "FDDI network controller",
+		0x03: "ATM network controller", 0x04: "ISDN controller", 0x07: "Infiniband controller",
+		0x08: "Fabric controller", 0x80: "Network controller",
+	}},
+	0x03: {"Display controller", map[uint8]string{
+		0x00: "VGA compatible controller", 0x01: "XGA compatible controller",
+		0x02: "3D controller", 0x80: "Display controller",
+	}},
+	0x04: {"Multimedia controller", map[uint8]string{
+		0x00: "Multimedia video controller", 0x01: "Multimedia audio controller",
+		0x03: "Audio device", 0x80: "Multimedia controller",
+	}},
+	0x05: {"Memory controller", map[uint8]string{0x00: "RAM memory", 0x01: "FLASH memory", 0x02: "CXL", 0x80: "Memory controller"}},
+	0x06: {"Bridge", map[uint8]string{
+		0x00: "Host bridge", 0x01: "ISA bridge", 0x02: "EISA bridge", 0x04: "PCI bridge",
+		0x07: "CardBus bridge", 0x09: "Semi-transparent PCI-to-PCI bridge", 0x80: "Bridge",
+	}},
+	0x07: {"Communication controller", map[uint8]string{0x00: "Serial controller", 0x03: "Modem", 0x80: "Communication controller"}},
+	0x08: {"Generic system peripheral", map[uint8]string{
+		0x00: "PIC", 0x01: "DMA controller", 0x02: "Timer", 0x03: "RTC",
+		0x05: "SD Host controller", 0x06: "IOMMU", 0x80: "System peripheral",
+	}},
+	0x09: {"Input device controller", nil},
+	0x0a: {"Docking station", nil},
+	0x0b: {"Processor", nil},
+	0x0c: {"Serial bus controller", map[uint8]string{
+		0x03: "USB controller", 0x04: "Fibre Channel", 0x05: "SMBus", 0x06: "InfiniBand", 0x07: "IPMI Interface",
+	}},
+	0x0d: {"Wireless controller", nil},
+	0x0e: {"Intelligent controller", nil},
+	0x0f: {"Satellite communications controller", nil},
+	0x10: {"Encryption controller", nil},
+	0x11: {"Signal processing controller", nil},
+	0x12: {"Processing accelerators", map[uint8]string{0x00: "Processing accelerators", 0x01: "SNIA Smart Data Accelerator Interface (SDXI) controller"}},
+	0x13: {"Non-Essential Instrumentation", nil},
+	0x40: {"Coprocessor", nil},
+	0xff: {"Unassigned class", nil},
+}
+
+func builtinClassNames(code ClassCode) (string, string, string) {
+	c, ok := builtinClasses[code.Base]
+	if !ok {
+		return "", "", ""
+	}
+	return c.name, c.subclasses[code.Sub], ""
+}
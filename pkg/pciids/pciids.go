@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pciids
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+)
+
+// Paths are the pci.ids locations tried by Load, relative to HostRoot.
+var Paths = []string{
+	"usr/share/hwdata/pci.ids",
+	"usr/share/misc/pci.ids",
+}
+
+// Database holds the vendor, device and class names of a pci.ids file.
+type Database struct {
+	vendors map[string]*vendor
+	classes map[string]*class
+}
+
+type vendor struct {
+	name    string
+	devices map[string]*device
+}
+
+type device struct {
+	name string
+	// subsystems is keyed by "subvendor subdevice".
+	subsystems map[string]string
+}
+
+type class struct {
+	name       string
+	subclasses map[string]*subclass
+}
+
+type subclass struct {
+	name    string
+	progIfs map[string]string
+}
+
+// Load parses the first pci.ids found under HostRoot. When none is found, the
+// database only knows the built-in class names.
+func Load() (*Database, error) {
+	for _, path := range Paths {
+		f, err := os.Open(cpuinfo.HostRoot(path))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return Parse(f)
+	}
+	return Parse(strings.NewReader(""))
+}
+
+// Parse reads a database in the pci.ids format.
+func Parse(r io.Reader) (*Database, error) {
+	db := &Database{
+		vendors: make(map[string]*vendor),
+		classes: make(map[string]*class),
+	}
+
+	var curVendor *vendor
+	var curDevice *device
+	var curClass *class
+	var curSubclass *subclass
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		depth := len(line) - len(strings.TrimLeft(line, "\t"))
+		line = line[depth:]
+
+		switch {
+		case depth == 0 && strings.HasPrefix(line, "C "):
+			id, name, ok := splitEntry(line[2:])
+			if !ok {
+				return nil, fmt.Errorf("pci.ids line %d: invalid class %q", lineNum, line)
+			}
+			curClass = &class{name: name, subclasses: make(map[string]*subclass)}
+			db.classes[id] = curClass
+			curVendor, curDevice, curSubclass = nil, nil, nil
+		case depth == 0:
+			id, name, ok := splitEntry(line)
+			if !ok {
+				// Other top level lists (e.g. "X" for other IDs) are not used.
+				curVendor, curDevice, curClass, curSubclass = nil, nil, nil, nil
+				continue
+			}
+			curVendor = &vendor{name: name, devices: make(map[string]*device)}
+			db.vendors[id] = curVendor
+			curDevice, curClass, curSubclass = nil, nil, nil
+		case depth == 1 && curVendor != nil:
+			id, name, ok := splitEntry(line)
+			if !ok {
+				return nil, fmt.Errorf("pci.ids line %d: invalid device %q", lineNum, line)
+			}
+			curDevice = &device{name: name, subsystems: make(map[string]string)}
+			curVendor.devices[id] = curDevice
+		case depth == 2 && curDevice != nil:
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("pci.ids line %d: invalid subsystem %q", lineNum, line)
+			}
+			id, name, ok := splitEntry(fields[1])
+			if !ok {
+				return nil, fmt.Errorf("pci.ids line %d: invalid subsystem %q", lineNum, line)
+			}
+			curDevice.subsystems[strings.ToLower(fields[0])+" "+id] = name
+		case depth == 1 && curClass != nil:
+			id, name, ok := splitEntry(line)
+			if !ok {
+				return nil, fmt.Errorf("pci.ids line %d: invalid subclass %q", lineNum, line)
+			}
+			curSubclass = &subclass{name: name, progIfs: make(map[string]string)}
+			curClass.subclasses[id] = curSubclass
+		case depth == 2 && curSubclass != nil:
+			id, name, ok := splitEntry(line)
+			if !ok {
+				return nil, fmt.Errorf("pci.ids line %d: invalid prog-if %q", lineNum, line)
+			}
+			curSubclass.progIfs[id] = name
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// splitEntry splits "id  name" into a lower case hex ID and its name.
+func splitEntry(s string) (string, string, bool) {
+	id, name, ok := strings.Cut(s, " ")
+	if !ok {
+		return "", "", false
+	}
+	if _, err := strconv.ParseUint(id, 16, 32); err != nil {
+		return "", "", false
+	}
+	return strings.ToLower(id), strings.TrimSpace(name), true
+}
+
+// VendorName returns the name of the vendor, or "" when unknown.
+func (db *Database) VendorName(vendorID string) string {
+	if v, ok := db.vendors[normalizeID(vendorID)]; ok {
+		return v.name
+	}
+	return ""
+}
+
+// DeviceName returns the name of the device, or "" when unknown.
+func (db *Database) DeviceName(vendorID, deviceID string) string {
+	if d := db.device(vendorID, deviceID); d != nil {
+		return d.name
+	}
+	return ""
+}
+
+// SubsystemName returns the name of the subsystem, or "" when unknown.
+func (db *Database) SubsystemName(vendorID, deviceID, subVendorID, subDeviceID string) string {
+	if d := db.device(vendorID, deviceID); d != nil {
+		return d.subsystems[normalizeID(subVendorID)+" "+normalizeID(subDeviceID)]
+	}
+	return ""
+}
+
+func (db *Database) device(vendorID, deviceID string) *device {
+	if v, ok := db.vendors[normalizeID(vendorID)]; ok {
+		return v.devices[normalizeID(deviceID)]
+	}
+	return nil
+}
+
+// ClassNames returns the names of the base class, subclass and programming
+// interface, falling back to the built-in class names. Unknown names are "".
+func (db *Database) ClassNames(code ClassCode) (string, string, string) {
+	base := fmt.Sprintf("%02x", code.Base)
+	sub := fmt.Sprintf("%02x", code.Sub)
+	progIf := fmt.Sprintf("%02x", code.ProgIf)
+
+	c, ok := db.classes[base]
+	if !ok {
+		return builtinClassNames(code)
+	}
+	baseName, subName, progIfName := c.name, "", ""
+	if s, ok := c.subclasses[sub]; ok {
+		subName = s.name
+		progIfName = s.progIfs[progIf]
+	}
+	return baseName, subName, progIfName
+}
+
+func normalizeID(id string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(id), "0x"))
+}
+
+// Names are the human readable names of a PCI device.
+type Names struct {
+	Vendor    string `json:"vendor,omitempty"`
+	Device    string `json:"device,omitempty"`
+	Subsystem string `json:"subsystem,omitempty"`
+	Class     string `json:"class,omitempty"`
+	Subclass  string `json:"subclass,omitempty"`
+	ProgIf    string `json:"progIf,omitempty"`
+}
+
+// Lookup resolves all names of a device. The class is the 24-bit sysfs class
+// code (e.g. "0x030200").
+func (db *Database) Lookup(vendorID, deviceID, subVendorID, subDeviceID, class string) Names {
+	names := Names{
+		Vendor:    db.VendorName(vendorID),
+		Device:    db.DeviceName(vendorID, deviceID),
+		Subsystem: db.SubsystemName(vendorID, deviceID, subVendorID, subDeviceID),
+	}
+	if code, err := ParseClassCode(class); err == nil {
+		names.Class, names.Subclass, names.ProgIf = db.ClassNames(code)
+	}
+	return names
+}
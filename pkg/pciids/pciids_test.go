@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package pciids
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPciIds = `#
+#	List of PCI ID's
+#
+# Syntax:
+# vendor  vendor_name
+#	device  device_name				<-- single tab
+#		subvendor subdevice  subsystem_name	<-- two tabs
+
+10de  NVIDIA Corporation
+	20b0  GA100 [A100 SXM4 40GB]
+		10de 1450  A100-SXM4-40GB
+	2330  GH100 [H100 SXM5 80GB]
+15b3  Mellanox Technologies
+	101d  MT2892 Family [ConnectX-6 Dx]
+
+# List of known device classes, subclasses and programming interfaces
+
+# Syntax:
+# C class	class_name
+#	subclass	subclass_name  		<-- single tab
+#		prog-if  prog-if_name  	<-- two tabs
+
+C 01  Mass storage controller
+	08  Non-Volatile memory controller
+		02  NVM Express
+C 03  Display controller
+	02  3D controller
+`
+
+func TestParse(t *testing.T) {
+	db, err := Parse(strings.NewReader(testPciIds))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name                                     string
+		vendor, device, subVendor, subDevice, cl string
+		want                                     Names
+	}{
+		{
+			name:      "all names",
+			vendor:    "10de",
+			device:    "20b0",
+			subVendor: "10de",
+			subDevice: "1450",
+			cl:        "0x030200",
+			want: Names{
+				Vendor:    "NVIDIA Corporation",
+				Device:    "GA100 [A100 SXM4 40GB]",
+				Subsystem: "A100-SXM4-40GB",
+				Class:     "Display controller",
+				Subclass:  "3D controller",
+			},
+		},
+		{
+			name:   "sysfs format",
+			vendor: "0x15B3",
+			device: "0x101d",
+			cl:     "0x020000\n",
+			want: Names{
+				Vendor: "Mellanox Technologies",
+				Device: "MT2892 Family [ConnectX-6 Dx]",
+				Class:  "Network controller",
+				// Built-in classes are used when pci.ids lacks the class.
+				Subclass: "Ethernet controller",
+			},
+		},
+		{
+			name:   "prog-if",
+			vendor: "144d",
+			device: "a80a",
+			cl:     "0x010802",
+			want: Names{
+				Class:    "Mass storage controller",
+				Subclass: "Non-Volatile memory controller",
+				ProgIf:   "NVM Express",
+			},
+		},
+		{
+			name:   "unknown",
+			vendor: "abcd",
+			device: "0001",
+			cl:     "0x770000",
+			want:   Names{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := db.Lookup(tt.vendor, tt.device, tt.subVendor, tt.subDevice, tt.cl)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("10de  NVIDIA\n\tzzzz  Bad\n")); err == nil {
+		t.Errorf("Parse() error = nil, want error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	hostRoot := t.TempDir()
+	t.Setenv("HOST_ROOT", hostRoot)
+
+	db, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := db.VendorName("10de"); got != "" {
+		t.Errorf("VendorName() = %q, want %q", got, "")
+	}
+
+	path := filepath.Join(hostRoot, "usr/share/misc/pci.ids")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(testPciIds), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	db, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, want := db.VendorName("10de"), "NVIDIA Corporation"; got != want {
+		t.Errorf("VendorName() = %q, want %q", got, want)
+	}
+}
+
+func TestParseClassCode(t *testing.T) {
+	tests := []struct {
+		class   string
+		want    ClassCode
+		wantErr bool
+	}{
+		{class: "0x030200", want: ClassCode{Base: 0x03, Sub: 0x02, ProgIf: 0x00}},
+		{class: "0x010802\n", want: ClassCode{Base: 0x01, Sub: 0x08, ProgIf: 0x02}},
+		{class: "060400", want: ClassCode{Base: 0x06, Sub: 0x04, ProgIf: 0x00}},
+		{class: "0x0302", wantErr: true},
+		{class: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			got, err := ParseClassCode(tt.class)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseClassCode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseClassCode() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && got.String() != "0x"+strings.TrimPrefix(strings.TrimSpace(tt.class), "0x") {
+				t.Errorf("ClassCode.String() = %v, want %v", got.String(), tt.class)
+			}
+		})
+	}
+}
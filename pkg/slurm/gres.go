@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurm
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/kelindar/bitmap"
+
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+const nvidiaVendorID = "10de"
+
+// GresConfig holds a single `gres.conf` line.
+type GresConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+	File string `json:"file"`
+
+	// Cores is the set of abstract CPUs local to the device.
+	Cores bitmap.Bitmap `json:"-"`
+}
+
+// String formats the GresConfig as a `gres.conf` line.
+func (g GresConfig) String() string {
+	fields := []string{"Name=" + g.Name}
+	if g.Type != "" {
+		fields = append(fields, "Type="+g.Type)
+	}
+	fields = append(fields, "File="+g.File)
+	if g.Cores.Count() > 0 {
+		fields = append(fields, "Cores="+FormatAbstractCPUs(g.Cores))
+	}
+	return strings.Join(fields, " ")
+}
+
+// NewGPUGresConfigs returns a `gres.conf` line for every NVIDIA GPU among the
+// devices, ordered by PCI address. The Cores of each GPU are the abstract CPUs
+// of the CPUMap local to it. GPUs whose device minor is not reported by the
+// NVIDIA driver are skipped.
+func NewGPUGresConfigs(devices []pcieinfo.PCIEDeviceInfo, cpuMap cpumap.CPUMap, gpuType string) ([]GresConfig, error) {
+	gpus := []pcieinfo.PCIEDeviceInfo{}
+	for _, device := range devices {
+		if device.VendorID == nvidiaVendorID && isGPUClass(device.Class) {
+			gpus = append(gpus, device)
+		}
+	}
+	slices.SortFunc(gpus, func(a, b pcieinfo.PCIEDeviceInfo) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+
+	gresConfigs := make([]GresConfig, 0, len(gpus))
+	for _, gpu := range gpus {
+		// Guessing the minor could give the device file of another GPU, with
+		// the wrong Cores.
+		minor, ok := pcieinfo.NVIDIAMinor(gpu.Address)
+		if !ok {
+			log.Printf("Warning: skipping GPU %s, its device minor is unknown (is the NVIDIA driver loaded?)", gpu.Address)
+			continue
+		}
+		localCpus, err := gpu.LocalCPUs()
+		if err != nil {
+			return nil, err
+		}
+		gresConfigs = append(gresConfigs, GresConfig{
+			Name:  "gpu",
+			Type:  gpuType,
+			File:  fmt.Sprintf("/dev/nvidia%d", minor),
+			Cores: cpuMap.ToAbstractCPUs(localCpus),
+		})
+	}
+	return gresConfigs, nil
+}
+
+// isGPUClass returns true for VGA and 3D controllers.
+func isGPUClass(class string) bool {
+	class = strings.TrimPrefix(class, "0x")
+	return strings.HasPrefix(class, "0300") || strings.HasPrefix(class, "0302")
+}
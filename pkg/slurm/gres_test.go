@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurm
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+func TestNewGPUGresConfigs(t *testing.T) {
+	devices := []pcieinfo.PCIEDeviceInfo{
+		{
+			Address:              "0000:c1:00.0",
+			VendorID:             "10de",
+			Class:                "0x030200",
+			NumaNodeAffinityMask: "0xff00ff00",
+		},
+		{
+			Address:              "0000:41:00.0",
+			VendorID:             "10de",
+			Class:                "0x030200",
+			NumaNodeAffinityMask: "0x00ff00ff",
+		},
+		{
+			Address:              "0000:42:00.0",
+			VendorID:             "15b3",
+			Class:                "0x020700",
+			NumaNodeAffinityMask: "0x00ff00ff",
+		},
+		{
+			Address:              "0000:02:00.0",
+			VendorID:             "10de",
+			Class:                "0x030000",
+			NumaNodeAffinityMask: "0x",
+		},
+	}
+
+	// The driver does not report 0000:02:00.0, which is skipped.
+	hostRoot := t.TempDir()
+	for address, minor := range map[string]string{"0000:c1:00.0": "7", "0000:41:00.0": "0"} {
+		infoPath := path.Join(hostRoot, "proc/driver/nvidia/gpus", address)
+		if err := os.MkdirAll(infoPath, 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		information := "Model: \t\t NVIDIA A100-SXM4-80GB\nIRQ:   \t\t 42\nDevice Minor: \t " + minor + "\n"
+		if err := os.WriteFile(path.Join(infoPath, "information"), []byte(information), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	t.Setenv("HOST_ROOT", hostRoot)
+
+	cpuMap := cpumap.NewCPUMap(testCpuInfos(2, 8, 2))
+	gresConfigs, err := NewGPUGresConfigs(devices, cpuMap, "a100")
+	if err != nil {
+		t.Fatalf("NewGPUGresConfigs() error = %v", err)
+	}
+	got := []string{}
+	for _, gresConfig := range gresConfigs {
+		got = append(got, gresConfig.String())
+	}
+	want := []string{
+		"Name=gpu Type=a100 File=/dev/nvidia0 Cores=0-7",
+		"Name=gpu Type=a100 File=/dev/nvidia7 Cores=8-15",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewGPUGresConfigs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurm
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kelindar/bitmap"
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+)
+
+// NodeConfig holds the hardware fields of a `slurm.conf` NodeName line.
+type NodeConfig struct {
+	NodeName        string `json:"nodeName"`
+	CPUs            int    `json:"cpus"`
+	Boards          int    `json:"boards"`
+	SocketsPerBoard int    `json:"socketsPerBoard"`
+	CoresPerSocket  int    `json:"coresPerSocket"`
+	ThreadsPerCore  int    `json:"threadsPerCore"`
+
+	// RealMemory is the total memory in MiB.
+	RealMemory int `json:"realMemory"`
+
+	// MemSpecLimit is the memory in MiB reserved for system use.
+	MemSpecLimit int `json:"memSpecLimit,omitempty"`
+
+	// CpuSpecList is the set of abstract CPUs reserved for system use.
+	CpuSpecList bitmap.Bitmap `json:"-"`
+}
+
+// NewNodeConfig derives the node layout from the CPUs and their CPUMap. It
+// fails when the sockets have different numbers of cores or the cores
+// different numbers of threads (e.g. hybrid P-core/E-core CPUs), as a
+// NodeName line cannot describe them and slurmd would reject it.
+func NewNodeConfig(nodeName string, cpuInfos []cpuinfo.CPUInfo, cpuMap cpumap.CPUMap) (NodeConfig, error) {
+	socketOf := make(map[int]int, len(cpuInfos))
+	for _, cpuInfo := range cpuInfos {
+		socketOf[cpuInfo.CpuId] = cpuInfo.SocketId
+	}
+
+	coresPerSocket := make(map[int]int)
+	threadsPerCore := make(map[int]bool)
+	for _, cpuSet := range cpuMap.AbstractToMachine {
+		if cpuSet.Size() == 0 {
+			continue
+		}
+		coresPerSocket[socketOf[cpuSet.List()[0]]]++
+		threadsPerCore[cpuSet.Size()] = true
+	}
+	coreCounts := make(map[int]bool)
+	for _, numCores := range coresPerSocket {
+		coreCounts[numCores] = true
+	}
+	if len(coreCounts) > 1 {
+		return NodeConfig{}, fmt.Errorf("sockets have different numbers of cores: %v", slices.Sorted(maps.Keys(coreCounts)))
+	}
+	if len(threadsPerCore) > 1 {
+		return NodeConfig{}, fmt.Errorf("cores have different numbers of threads: %v", slices.Sorted(maps.Keys(threadsPerCore)))
+	}
+
+	nodeConfig := NodeConfig{
+		NodeName:        nodeName,
+		CPUs:            len(cpuInfos),
+		Boards:          1,
+		SocketsPerBoard: len(coresPerSocket),
+	}
+	for numCores := range coreCounts {
+		nodeConfig.CoresPerSocket = numCores
+	}
+	for numThreads := range threadsPerCore {
+		nodeConfig.ThreadsPerCore = numThreads
+	}
+	return nodeConfig, nil
+}
+
+// String formats the NodeConfig as a `slurm.conf` NodeName line.
+func (c NodeConfig) String() string {
+	fields := []string{
+		"NodeName=" + c.NodeName,
+		"CPUs=" + strconv.Itoa(c.CPUs),
+		"Boards=" + strconv.Itoa(c.Boards),
+		"SocketsPerBoard=" + strconv.Itoa(c.SocketsPerBoard),
+		"CoresPerSocket=" + strconv.Itoa(c.CoresPerSocket),
+		"ThreadsPerCore=" + strconv.Itoa(c.ThreadsPerCore),
+		"RealMemory=" + strconv.Itoa(c.RealMemory),
+	}
+	if c.MemSpecLimit > 0 {
+		fields = append(fields, "MemSpecLimit="+strconv.Itoa(c.MemSpecLimit))
+	}
+	if c.CpuSpecList.Count() > 0 {
+		fields = append(fields, "CpuSpecList="+FormatAbstractCPUs(c.CpuSpecList))
+	}
+	return strings.Join(fields, " ")
+}
+
+// ToCpuSpecList converts reserved machine CPUs into the abstract CPUs of the
+// CPUMap. Reserving any thread of a core reserves the whole core.
+func ToCpuSpecList(cpuMap cpumap.CPUMap, reserved cpuset.CPUSet) (bitmap.Bitmap, error) {
+	return MachineToAbstract(cpuMap, reserved)
+}
+
+// FormatAbstractCPUs formats the abstract CPUs as a Slurm range list
+// (e.g. "0-3,8").
+func FormatAbstractCPUs(absBitmap bitmap.Bitmap) string {
+	return cpuset.New(bitmaputil.List(absBitmap)...).String()
+}
+
+// GetRealMemory returns the total memory of the host in MiB.
+func GetRealMemory() (int, error) {
+	filename := cpuinfo.HostProc("meminfo")
+	lines, err := cpuinfo.ReadLines(filename)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kib, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse %q: %w", line, err)
+		}
+		return kib / 1024, nil
+	}
+	return 0, fmt.Errorf("MemTotal not found in %s", filename)
+}
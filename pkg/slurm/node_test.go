@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurm
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+)
+
+// testCpuInfos returns a machine with the given layout, enumerating SMT
+// siblings the way Linux does (all first threads, then all second threads).
+func testCpuInfos(sockets, coresPerSocket, threadsPerCore int) []cpuinfo.CPUInfo {
+	cpuInfos := []cpuinfo.CPUInfo{}
+	cpuId := 0
+	for range threadsPerCore {
+		for socketId := range sockets {
+			for coreId := range coresPerSocket {
+				cpuInfos = append(cpuInfos, cpuinfo.CPUInfo{
+					CpuId:    cpuId,
+					SocketId: socketId,
+					CoreId:   coreId,
+					NumaNode: socketId,
+				})
+				cpuId++
+			}
+		}
+	}
+	return cpuInfos
+}
+
+// testHybridCpuInfos returns a single socket machine with SMT2 P-cores
+// followed by single threaded E-cores, enumerated like Intel hybrid CPUs.
+func testHybridCpuInfos(pCores, eCores int) []cpuinfo.CPUInfo {
+	cpuInfos := []cpuinfo.CPUInfo{}
+	for coreId := range pCores {
+		for thread := range 2 {
+			cpuInfos = append(cpuInfos, cpuinfo.CPUInfo{
+				CpuId:  coreId*2 + thread,
+				CoreId: coreId * 4,
+			})
+		}
+	}
+	for i := range eCores {
+		cpuInfos = append(cpuInfos, cpuinfo.CPUInfo{
+			CpuId:  pCores*2 + i,
+			CoreId: pCores*4 + i,
+		})
+	}
+	return cpuInfos
+}
+
+func TestNodeConfig_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		cpuInfos []cpuinfo.CPUInfo
+		reserved cpuset.CPUSet
+		memSpec  int
+		want     string
+		wantErr  bool
+		// wantConfigErr is set when the layout cannot be described.
+		wantConfigErr bool
+	}{
+		{
+			name:     "2 sockets SMT2",
+			cpuInfos: testCpuInfos(2, 4, 2),
+			reserved: cpuset.New(),
+			want:     "NodeName=node0 CPUs=16 Boards=1 SocketsPerBoard=2 CoresPerSocket=4 ThreadsPerCore=2 RealMemory=1024",
+		},
+		{
+			name:     "reserved cores",
+			cpuInfos: testCpuInfos(2, 4, 2),
+			reserved: cpuset.New(0, 12, 13),
+			memSpec:  512,
+			want:     "NodeName=node0 CPUs=16 Boards=1 SocketsPerBoard=2 CoresPerSocket=4 ThreadsPerCore=2 RealMemory=1024 MemSpecLimit=512 CpuSpecList=0,4-5",
+		},
+		{
+			name:          "hybrid cores",
+			cpuInfos:      testHybridCpuInfos(2, 4),
+			reserved:      cpuset.New(),
+			wantConfigErr: true,
+		},
+		{
+			name:          "uneven sockets",
+			cpuInfos:      append(testCpuInfos(1, 4, 1), cpuinfo.CPUInfo{CpuId: 4, SocketId: 1, CoreId: 0, NumaNode: 1}),
+			reserved:      cpuset.New(),
+			wantConfigErr: true,
+		},
+		{
+			name:     "unknown reserved CPU",
+			cpuInfos: testCpuInfos(1, 4, 1),
+			reserved: cpuset.New(4),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpuMap := cpumap.NewCPUMap(tt.cpuInfos)
+			nodeConfig, err := NewNodeConfig("node0", tt.cpuInfos, cpuMap)
+			if (err != nil) != tt.wantConfigErr {
+				t.Errorf("NewNodeConfig() error = %v, wantErr %v", err, tt.wantConfigErr)
+				return
+			}
+			if tt.wantConfigErr {
+				return
+			}
+			nodeConfig.RealMemory = 1024
+			nodeConfig.MemSpecLimit = tt.memSpec
+			cpuSpecList, err := ToCpuSpecList(cpuMap, tt.reserved)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ToCpuSpecList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			nodeConfig.CpuSpecList = cpuSpecList
+			if got := nodeConfig.String(); got != tt.want {
+				t.Errorf("NodeConfig.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRealMemory(t *testing.T) {
+	tests := []struct {
+		name    string
+		meminfo string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "MemTotal",
+			meminfo: "MemTotal:       65536000 kB\nMemFree:        1024 kB\n",
+			want:    64000,
+		},
+		{
+			name:    "missing MemTotal",
+			meminfo: "MemFree:        1024 kB\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostRoot := t.TempDir()
+			if err := os.MkdirAll(path.Join(hostRoot, "proc"), 0o755); err != nil {
+				t.Fatalf("MkdirAll() error = %v", err)
+			}
+			if err := os.WriteFile(path.Join(hostRoot, "proc/meminfo"), []byte(tt.meminfo), 0o644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			t.Setenv("HOST_ROOT", hostRoot)
+
+			got, err := GetRealMemory()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetRealMemory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetRealMemory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kelindar/bitmap"
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+)
+
+// ParseCPUList parses either a range list (e.g. "0-3,8") or a hex mask
+// (e.g. "0x10f") as found in SLURM_CPU_BIND_LIST.
+func ParseCPUList(s string) (bitmap.Bitmap, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return bitmaputil.NewFrom(strings.ToLower(s))
+	}
+	cpuSet, err := cpuset.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return bitmaputil.New(cpuSet.List()...), nil
+}
+
+// SplitMaskList splits a comma separated list of hex masks (e.g.
+// SLURM_CPU_BIND_LIST="0x3,0xc") into its masks. Anything else, including a
+// range list, is returned as a single element.
+func SplitMaskList(s string) []string {
+	masks := strings.Split(strings.TrimSpace(s), ",")
+	for i, mask := range masks {
+		mask = strings.TrimSpace(mask)
+		if !strings.HasPrefix(mask, "0x") && !strings.HasPrefix(mask, "0X") {
+			return []string{s}
+		}
+		masks[i] = mask
+	}
+	return masks
+}
+
+// AbstractToMachine converts abstract CPUs into machine CPUs, failing on
+// abstract CPUs that do not exist in the CPUMap.
+func AbstractToMachine(cpuMap cpumap.CPUMap, absBitmap bitmap.Bitmap) (cpuset.CPUSet, error) {
+	for _, absIdx := range bitmaputil.List(absBitmap) {
+		if absIdx >= len(cpuMap.AbstractToMachine) {
+			return cpuset.New(), fmt.Errorf("abstract CPU %d is not available", absIdx)
+		}
+	}
+	return cpuMap.ToMachineCPUs(absBitmap), nil
+}
+
+// MachineToAbstract converts machine CPUs into abstract CPUs, failing on
+// machine CPUs that do not exist in the CPUMap (e.g. avoided E-Cores).
+func MachineToAbstract(cpuMap cpumap.CPUMap, macCpuSet cpuset.CPUSet) (bitmap.Bitmap, error) {
+	for _, cpuId := range macCpuSet.List() {
+		if _, ok := cpuMap.MachineToAbstract[cpuId]; !ok {
+			return nil, fmt.Errorf("machine CPU %d is not available", cpuId)
+		}
+	}
+	return cpuMap.ToAbstractCPUs(macCpuSet), nil
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package slurm
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/bitmaputil"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+)
+
+func TestParseCPUList(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []int
+		wantErr bool
+	}{
+		{name: "range list", s: "0-3,8", want: []int{0, 1, 2, 3, 8}},
+		{name: "hex mask", s: "0x10f", want: []int{0, 1, 2, 3, 8}},
+		{name: "upper case hex mask", s: "0X10F", want: []int{0, 1, 2, 3, 8}},
+		{name: "empty", s: "", want: []int{}},
+		{name: "invalid", s: "0-x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCPUList(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCPUList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if list := bitmaputil.List(got); !reflect.DeepEqual(list, tt.want) {
+				t.Errorf("ParseCPUList() = %v, want %v", list, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitMaskList(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{name: "mask list", s: "0x3,0xc", want: []string{"0x3", "0xc"}},
+		{name: "mask list with spaces", s: "0x3, 0xc", want: []string{"0x3", "0xc"}},
+		{name: "range list", s: "0-3,8", want: []string{"0-3,8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitMaskList(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitMaskList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	cpuMap := cpumap.NewCPUMap(testCpuInfos(2, 4, 2))
+
+	macCpuSet, err := AbstractToMachine(cpuMap, bitmaputil.New(0, 5))
+	if err != nil {
+		t.Fatalf("AbstractToMachine() error = %v", err)
+	}
+	if want := cpuset.New(0, 5, 8, 13); !macCpuSet.Equals(want) {
+		t.Errorf("AbstractToMachine() = %v, want %v", macCpuSet, want)
+	}
+	if _, err := AbstractToMachine(cpuMap, bitmaputil.New(8)); err == nil {
+		t.Errorf("AbstractToMachine() error = nil, want error")
+	}
+
+	absBitmap, err := MachineToAbstract(cpuMap, cpuset.New(0, 13))
+	if err != nil {
+		t.Fatalf("MachineToAbstract() error = %v", err)
+	}
+	if want := bitmaputil.New(0, 5); !reflect.DeepEqual(absBitmap, want) {
+		t.Errorf("MachineToAbstract() = %v, want %v", bitmaputil.String(absBitmap), bitmaputil.String(want))
+	}
+	if _, err := MachineToAbstract(cpuMap, cpuset.New(16)); err == nil {
+		t.Errorf("MachineToAbstract() error = nil, want error")
+	}
+}
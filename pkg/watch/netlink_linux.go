@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"syscall"
+)
+
+// NetlinkSource receives the uevents the kernel broadcasts over netlink.
+// Only the host network namespace receives them.
+type NetlinkSource struct {
+	fd int
+}
+
+// NewNetlinkSource opens a netlink socket subscribed to kernel uevents.
+func NewNetlinkSource() (*NetlinkSource, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uevent socket: %w", err)
+	}
+	// Group 1 receives the events of the kernel, not those of udev.
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind uevent socket: %w", err)
+	}
+	// Wake up regularly to notice the context being canceled.
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &syscall.Timeval{Sec: 1}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to set uevent socket timeout: %w", err)
+	}
+	return &NetlinkSource{fd: fd}, nil
+}
+
+// Run sends the uevents received until the context is canceled. When the
+// socket overflows, it sends a resync uevent for the lost ones.
+func (s *NetlinkSource) Run(ctx context.Context, uevents chan<- Uevent) error {
+	buf := make([]byte, 64*1024)
+	for ctx.Err() == nil {
+		n, _, err := syscall.Recvfrom(s.fd, buf, 0)
+		switch {
+		case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+			continue
+		case errors.Is(err, syscall.ENOBUFS):
+			log.Printf("Warning: uevent socket overflowed, events were lost, scanning again")
+			select {
+			case uevents <- Uevent{Action: ResyncAction}:
+			case <-ctx.Done():
+			}
+			continue
+		case err != nil:
+			return fmt.Errorf("failed to receive uevent: %w", err)
+		}
+
+		u, err := ParseUevent(buf[:n])
+		if err != nil {
+			continue
+		}
+		select {
+		case uevents <- u:
+		case <-ctx.Done():
+		}
+	}
+	return nil
+}
+
+// Close closes the netlink socket.
+func (s *NetlinkSource) Close() error {
+	return syscall.Close(s.fd)
+}
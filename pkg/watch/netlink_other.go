@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package watch
+
+import (
+	"context"
+	"errors"
+)
+
+var errNetlinkUnsupported = errors.New("netlink uevents are only supported on Linux")
+
+// NetlinkSource receives the uevents the kernel broadcasts over netlink.
+type NetlinkSource struct{}
+
+// NewNetlinkSource always fails outside of Linux.
+func NewNetlinkSource() (*NetlinkSource, error) {
+	return nil, errNetlinkUnsupported
+}
+
+// Run always fails outside of Linux.
+func (s *NetlinkSource) Run(ctx context.Context, uevents chan<- Uevent) error {
+	return errNetlinkUnsupported
+}
+
+// Close does nothing outside of Linux.
+func (s *NetlinkSource) Close() error {
+	return nil
+}
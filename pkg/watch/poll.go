@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package watch
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+)
+
+// DefaultPollInterval is the interval of a PollSource without one.
+const DefaultPollInterval = 2 * time.Second
+
+// PollSource synthesizes uevents by scanning sysfs at regular intervals, for
+// when the netlink socket is unavailable (e.g. outside of the host network
+// namespace). Changes between two scans are reported in a single batch.
+//
+// Every scan lists the devices and reads their driver links rather than
+// skipping those whose sysfs mtimes did not change: sysfs only updates the
+// mtime of a directory that already carries explicit attributes, so most
+// hotplugs and driver binds leave the mtimes untouched.
+type PollSource struct {
+	Interval time.Duration
+}
+
+// NewPollSource returns a PollSource scanning sysfs at the interval.
+func NewPollSource(interval time.Duration) *PollSource {
+	return &PollSource{Interval: interval}
+}
+
+// Run sends the uevents of the changes between scans until the context is
+// canceled.
+func (s *PollSource) Run(ctx context.Context, uevents chan<- Uevent) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	state := readSysfsState()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		next := readSysfsState()
+		for _, u := range state.diff(next) {
+			select {
+			case uevents <- u:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		state = next
+	}
+}
+
+// pciState is the state of a PCI device as seen by a scan.
+type pciState struct {
+	devPath string
+	driver  string
+}
+
+// sysfsState is the state of the CPUs and PCI devices as seen by a scan.
+type sysfsState struct {
+	onlineCPUs cpuset.CPUSet
+	devices    map[string]pciState
+}
+
+func readSysfsState() sysfsState {
+	state := sysfsState{
+		onlineCPUs: cpuset.New(),
+		devices:    map[string]pciState{},
+	}
+	if online, err := cpuinfo.ReadFile(cpuinfo.HostSys("devices/system/cpu/online")); err == nil {
+		if cpus, err := cpuset.Parse(strings.TrimSpace(online)); err == nil {
+			state.onlineCPUs = cpus
+		}
+	}
+
+	pciPath := cpuinfo.HostSys("bus/pci/devices")
+	entries, err := os.ReadDir(pciPath)
+	if err != nil {
+		return state
+	}
+	for _, entry := range entries {
+		path := filepath.Join(pciPath, entry.Name())
+		realPath, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			continue
+		}
+		devPath, err := filepath.Rel(cpuinfo.HostSys(), realPath)
+		if err != nil {
+			continue
+		}
+		driver := ""
+		if link, err := os.Readlink(filepath.Join(path, "driver")); err == nil {
+			driver = filepath.Base(link)
+		}
+		state.devices[entry.Name()] = pciState{devPath: "/" + devPath, driver: driver}
+	}
+	return state
+}
+
+// diff returns the uevents turning the state into the next one: removals
+// and unbinds first, then additions and binds, each ordered by CPU or
+// address.
+func (s sysfsState) diff(next sysfsState) []Uevent {
+	uevents := []Uevent{}
+	for _, cpu := range s.onlineCPUs.Difference(next.onlineCPUs).List() {
+		uevents = append(uevents, cpuUevent("offline", cpu))
+	}
+	for _, address := range slices.Sorted(maps.Keys(s.devices)) {
+		old := s.devices[address]
+		current, ok := next.devices[address]
+		if old.driver != "" && (!ok || current.driver != old.driver) {
+			uevents = append(uevents, pciUevent("unbind", address, old.devPath, old.driver))
+		}
+		if !ok {
+			uevents = append(uevents, pciUevent("remove", address, old.devPath, ""))
+		}
+	}
+
+	for _, cpu := range next.onlineCPUs.Difference(s.onlineCPUs).List() {
+		uevents = append(uevents, cpuUevent("online", cpu))
+	}
+	for _, address := range slices.Sorted(maps.Keys(next.devices)) {
+		current := next.devices[address]
+		old, ok := s.devices[address]
+		if !ok {
+			uevents = append(uevents, pciUevent("add", address, current.devPath, ""))
+		}
+		if current.driver != "" && (!ok || current.driver != old.driver) {
+			uevents = append(uevents, pciUevent("bind", address, current.devPath, current.driver))
+		}
+	}
+	return uevents
+}
+
+func cpuUevent(action string, cpu int) Uevent {
+	devPath := "/devices/system/cpu/cpu" + strconv.Itoa(cpu)
+	return Uevent{
+		Action:    action,
+		DevPath:   devPath,
+		Subsystem: "cpu",
+		Env:       map[string]string{"ACTION": action, "DEVPATH": devPath, "SUBSYSTEM": "cpu"},
+	}
+}
+
+func pciUevent(action, address, devPath, driver string) Uevent {
+	u := Uevent{
+		Action:    action,
+		DevPath:   devPath,
+		Subsystem: "pci",
+		Env: map[string]string{
+			"ACTION":        action,
+			"DEVPATH":       devPath,
+			"SUBSYSTEM":     "pci",
+			"PCI_SLOT_NAME": address,
+		},
+	}
+	if driver != "" {
+		u.Env["DRIVER"] = driver
+	}
+	return u
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package watch
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/pravk03/topologyutil/pkg/internal/sysfstest"
+)
+
+func TestReadSysfsState(t *testing.T) {
+	hostRoot := sysfstest.NewHostRoot(t)
+	sysfstest.WriteFile(t, hostRoot, "sys/devices/system/cpu/online", "0-2,5\n")
+	addDevice(t, hostRoot, "0000:00:01.0")
+	addDevice(t, hostRoot, "0000:00:02.0")
+	sysfstest.Symlink(t, hostRoot, "sys/bus/pci/drivers/nvme", "sys/devices/pci0000:00/0000:00:02.0/driver")
+
+	got := readSysfsState()
+	want := sysfsState{
+		onlineCPUs: cpuset.New(0, 1, 2, 5),
+		devices: map[string]pciState{
+			"0000:00:01.0": {devPath: "/devices/pci0000:00/0000:00:01.0"},
+			"0000:00:02.0": {devPath: "/devices/pci0000:00/0000:00:02.0", driver: "nvme"},
+		},
+	}
+	if !got.onlineCPUs.Equals(want.onlineCPUs) || !reflect.DeepEqual(got.devices, want.devices) {
+		t.Errorf("readSysfsState() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSysfsStateDiff(t *testing.T) {
+	state := func(cpus cpuset.CPUSet, devices map[string]pciState) sysfsState {
+		return sysfsState{onlineCPUs: cpus, devices: devices}
+	}
+	tests := []struct {
+		name string
+		old  sysfsState
+		next sysfsState
+		want []string
+	}{
+		{
+			name: "unchanged",
+			old:  state(cpuset.New(0, 1), map[string]pciState{"0000:00:01.0": {driver: "nvme"}}),
+			next: state(cpuset.New(0, 1), map[string]pciState{"0000:00:01.0": {driver: "nvme"}}),
+			want: []string{},
+		},
+		{
+			name: "cpus",
+			old:  state(cpuset.New(0, 1, 2), nil),
+			next: state(cpuset.New(0, 3), nil),
+			want: []string{"offline cpu1", "offline cpu2", "online cpu3"},
+		},
+		{
+			name: "vf created and bound",
+			old:  state(cpuset.New(), map[string]pciState{"0000:81:00.0": {driver: "mlx5_core"}}),
+			next: state(cpuset.New(), map[string]pciState{
+				"0000:81:00.0": {driver: "mlx5_core"},
+				"0000:81:00.2": {driver: "mlx5_core"},
+			}),
+			want: []string{"add 0000:81:00.2", "bind 0000:81:00.2 mlx5_core"},
+		},
+		{
+			name: "driver replaced",
+			old:  state(cpuset.New(), map[string]pciState{"0000:81:00.2": {driver: "mlx5_core"}}),
+			next: state(cpuset.New(), map[string]pciState{"0000:81:00.2": {driver: "vfio-pci"}}),
+			want: []string{"unbind 0000:81:00.2 mlx5_core", "bind 0000:81:00.2 vfio-pci"},
+		},
+		{
+			name: "bound device removed",
+			old:  state(cpuset.New(), map[string]pciState{"0000:81:00.2": {driver: "vfio-pci"}}),
+			next: state(cpuset.New(), map[string]pciState{}),
+			want: []string{"unbind 0000:81:00.2 vfio-pci", "remove 0000:81:00.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, u := range tt.old.diff(tt.next) {
+				switch {
+				case u.Subsystem == "cpu":
+					got = append(got, u.Action+" "+filepath.Base(u.DevPath))
+				case u.Env["DRIVER"] != "":
+					got = append(got, u.Action+" "+u.Env["PCI_SLOT_NAME"]+" "+u.Env["DRIVER"])
+				default:
+					got = append(got, u.Action+" "+u.Env["PCI_SLOT_NAME"])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
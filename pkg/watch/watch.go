@@ -0,0 +1,305 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package watch follows CPU and PCIe hotplug through kernel uevents, keeping
+// the CPU map and the PCIe inventory up to date.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/cpumap"
+	"github.com/pravk03/topologyutil/pkg/pcieinfo"
+)
+
+// EventType is the kind of topology change.
+type EventType string
+
+const (
+	CPUOnline    EventType = "cpu-online"
+	CPUOffline   EventType = "cpu-offline"
+	PCIAdd       EventType = "pci-add"
+	PCIRemove    EventType = "pci-remove"
+	DriverBind   EventType = "driver-bind"
+	DriverUnbind EventType = "driver-unbind"
+
+	// Resync follows lost events: the CPUs and devices were scanned again.
+	Resync EventType = "resync"
+)
+
+// ResyncAction is the action of the uevent a source sends when it lost
+// uevents, for the Watcher to scan the CPUs and devices again.
+const ResyncAction = "resync"
+
+// Event is a topology change.
+type Event struct {
+	Type EventType `json:"type"`
+	// CPU is the machine CPU of CPU events.
+	CPU int `json:"cpu,omitempty"`
+	// Address is the PCI address of PCI and driver events.
+	Address string `json:"address,omitempty"`
+	// Driver is the driver bound or unbound by driver events.
+	Driver string `json:"driver,omitempty"`
+	// Device is the device after PCI and driver events, or before it for
+	// removals. It is nil for CPU events.
+	Device *pcieinfo.PCIEDeviceInfo `json:"device,omitempty"`
+}
+
+// String returns a one line description of the event.
+func (e Event) String() string {
+	switch e.Type {
+	case CPUOnline, CPUOffline:
+		return fmt.Sprintf("%s cpu=%d", e.Type, e.CPU)
+	case DriverBind, DriverUnbind:
+		return fmt.Sprintf("%s %s %s", e.Type, e.Address, e.Driver)
+	case Resync:
+		return string(e.Type)
+	default:
+		return fmt.Sprintf("%s %s", e.Type, e.Address)
+	}
+}
+
+// Uevent is a kernel object event, as broadcast over netlink.
+type Uevent struct {
+	Action    string
+	DevPath   string
+	Subsystem string
+	// Env holds every KEY=VALUE pair of the event.
+	Env map[string]string
+}
+
+// ParseUevent parses a kernel uevent message: an "ACTION@DEVPATH" header
+// followed by NUL separated KEY=VALUE pairs.
+func ParseUevent(msg []byte) (Uevent, error) {
+	fields := strings.Split(strings.TrimRight(string(msg), "\x00"), "\x00")
+	action, devPath, ok := strings.Cut(fields[0], "@")
+	if !ok {
+		return Uevent{}, fmt.Errorf("invalid uevent header %q", fields[0])
+	}
+	u := Uevent{
+		Action:  action,
+		DevPath: devPath,
+		Env:     make(map[string]string, len(fields)-1),
+	}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		u.Env[key] = value
+	}
+	if action, ok := u.Env["ACTION"]; ok {
+		u.Action = action
+	}
+	if devPath, ok := u.Env["DEVPATH"]; ok {
+		u.DevPath = devPath
+	}
+	u.Subsystem = u.Env["SUBSYSTEM"]
+	return u, nil
+}
+
+// eventOf returns the topology change of the uevent, if any.
+func eventOf(u Uevent) (Event, bool) {
+	if u.Action == ResyncAction {
+		return Event{Type: Resync}, true
+	}
+	switch u.Subsystem {
+	case "cpu":
+		cpu, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(u.DevPath), "cpu"))
+		if err != nil {
+			return Event{}, false
+		}
+		switch u.Action {
+		case "online":
+			return Event{Type: CPUOnline, CPU: cpu}, true
+		case "offline":
+			return Event{Type: CPUOffline, CPU: cpu}, true
+		}
+	case "pci":
+		address := u.Env["PCI_SLOT_NAME"]
+		if address == "" {
+			address = filepath.Base(u.DevPath)
+		}
+		switch u.Action {
+		case "add":
+			return Event{Type: PCIAdd, Address: address}, true
+		case "remove":
+			return Event{Type: PCIRemove, Address: address}, true
+		case "bind":
+			return Event{Type: DriverBind, Address: address, Driver: u.Env["DRIVER"]}, true
+		case "unbind":
+			return Event{Type: DriverUnbind, Address: address, Driver: u.Env["DRIVER"]}, true
+		}
+	}
+	return Event{}, false
+}
+
+// Source produces kernel uevents.
+type Source interface {
+	// Run sends uevents until the context is canceled or an error occurs.
+	// It must not close the channel. When uevents are lost, it sends a
+	// uevent with the ResyncAction instead.
+	Run(ctx context.Context, uevents chan<- Uevent) error
+}
+
+// Watcher keeps the CPU map and the PCIe inventory up to date with the
+// events of a source.
+type Watcher struct {
+	source       Source
+	readCPUInfos func() ([]cpuinfo.CPUInfo, error)
+
+	mu       sync.RWMutex
+	cpuInfos []cpuinfo.CPUInfo
+	cpuMap   cpumap.CPUMap
+	pcieInfo *pcieinfo.PCIEInfo
+}
+
+// Option configures a Watcher.
+type Option func(w *Watcher)
+
+// WithCPUInfoOptions passes the options to cpuinfo.GetCPUInfos whenever the
+// CPUs are read.
+func WithCPUInfoOptions(options ...cpuinfo.CPUInfoOption) Option {
+	return func(w *Watcher) {
+		w.readCPUInfos = func() ([]cpuinfo.CPUInfo, error) {
+			return cpuinfo.GetCPUInfos(options...)
+		}
+	}
+}
+
+// NewWatcher scans the CPUs and PCIe devices and returns a Watcher updating
+// them with the events of the source.
+func NewWatcher(source Source, options ...Option) (*Watcher, error) {
+	w := &Watcher{
+		source: source,
+		readCPUInfos: func() ([]cpuinfo.CPUInfo, error) {
+			return cpuinfo.GetCPUInfos()
+		},
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+
+	cpuInfos, err := w.readCPUInfos()
+	if err != nil {
+		return nil, err
+	}
+	// Report inconsistent thread siblings once rather than on every rebuild.
+	for _, problem := range cpumap.CheckThreadSiblings(cpuInfos) {
+		log.Printf("Warning: %s", problem)
+	}
+	w.setCPUInfos(cpuInfos)
+	if w.pcieInfo, err = pcieinfo.NewPCIEInfo(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// CPUInfos returns the online CPUs.
+func (w *Watcher) CPUInfos() []cpuinfo.CPUInfo {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return slices.Clone(w.cpuInfos)
+}
+
+// CPUMap returns the CPU map of the online CPUs.
+func (w *Watcher) CPUMap() cpumap.CPUMap {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cpuMap
+}
+
+// PCIEInfo returns the PCIe inventory. It is never modified, later events
+// replace it.
+func (w *Watcher) PCIEInfo() *pcieinfo.PCIEInfo {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.pcieInfo
+}
+
+// Run applies the events of the source until it stops, calling the handler
+// after each topology change. It returns nil once the context is canceled.
+func (w *Watcher) Run(ctx context.Context, handler func(Event)) error {
+	uevents := make(chan Uevent, 64)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- w.source.Run(ctx, uevents)
+		close(uevents)
+	}()
+
+	for u := range uevents {
+		event, ok := eventOf(u)
+		if !ok {
+			continue
+		}
+		if err := w.apply(&event); err != nil {
+			log.Printf("Warning: failed to apply %s: %v", event, err)
+			continue
+		}
+		handler(event)
+	}
+	if err := <-errs; err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// apply updates the CPUs or devices changed by the event, completing it.
+func (w *Watcher) apply(event *Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch event.Type {
+	case Resync:
+		cpuInfos, err := w.readCPUInfos()
+		if err != nil {
+			return err
+		}
+		pcieInfo, err := pcieinfo.NewPCIEInfo()
+		if err != nil {
+			return err
+		}
+		w.setCPUInfos(cpuInfos)
+		w.pcieInfo = pcieInfo
+	case CPUOnline:
+		// The topology of the new CPU is only known from a new scan.
+		cpuInfos, err := w.readCPUInfos()
+		if err != nil {
+			return err
+		}
+		w.setCPUInfos(cpuInfos)
+	case CPUOffline:
+		w.setCPUInfos(slices.DeleteFunc(slices.Clone(w.cpuInfos), func(cpuInfo cpuinfo.CPUInfo) bool {
+			return cpuInfo.CpuId == event.CPU
+		}))
+	case PCIAdd, PCIRemove, DriverBind, DriverUnbind:
+		old, hadOld := w.pcieInfo.FindDeviceByAddress(event.Address)
+		w.pcieInfo = w.pcieInfo.Refresh(event.Address)
+		device, ok := w.pcieInfo.FindDeviceByAddress(event.Address)
+		if !ok && hadOld {
+			device, ok = old, true
+		}
+		if ok {
+			event.Device = &device
+		}
+		// Unbind events no longer know the driver.
+		if event.Type == DriverUnbind && event.Driver == "" && hadOld {
+			event.Driver = old.Driver
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) setCPUInfos(cpuInfos []cpuinfo.CPUInfo) {
+	w.cpuInfos = cpuInfos
+	// NewCPUMap sorts the CPUs it is given.
+	w.cpuMap = cpumap.NewCPUMap(slices.Clone(cpuInfos))
+}
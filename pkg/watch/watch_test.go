@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: Copyright (C) SchedMD LLC.
+// SPDX-License-Identifier: Apache-2.0
+
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/pravk03/topologyutil/pkg/cpuinfo"
+	"github.com/pravk03/topologyutil/pkg/internal/sysfstest"
+)
+
+func remove(t *testing.T, name string) {
+	t.Helper()
+	if err := os.Remove(name); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+}
+
+// addDevice adds a NIC to the fake sysfs tree and returns its path.
+func addDevice(t *testing.T, hostRoot, address string) string {
+	t.Helper()
+	return sysfstest.AddDevice(t, hostRoot, sysfstest.Device{
+		Path: "pci0000:00/" + address,
+		Files: map[string]string{
+			"vendor":    "0x15b3\n",
+			"device":    "0x101d\n",
+			"class":     "0x020000\n",
+			"numa_node": "0\n",
+		},
+	})
+}
+
+// step is a change of the fake sysfs tree and its uevent.
+type step struct {
+	prepare func()
+	uevent  Uevent
+}
+
+// fakeSource sends the uevents of its steps, waiting for each to be applied
+// before preparing the next one.
+type fakeSource struct {
+	steps   []step
+	applied chan struct{}
+}
+
+func (s *fakeSource) Run(ctx context.Context, uevents chan<- Uevent) error {
+	for i, step := range s.steps {
+		if i > 0 {
+			select {
+			case <-s.applied:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		if step.prepare != nil {
+			step.prepare()
+		}
+		select {
+		case uevents <- step.uevent:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+func withCPUInfos(readCPUInfos func() ([]cpuinfo.CPUInfo, error)) Option {
+	return func(w *Watcher) {
+		w.readCPUInfos = readCPUInfos
+	}
+}
+
+func testCPUInfos(cpus ...int) []cpuinfo.CPUInfo {
+	cpuInfos := []cpuinfo.CPUInfo{}
+	for _, cpu := range cpus {
+		cpuInfos = append(cpuInfos, cpuinfo.CPUInfo{
+			CpuId:          cpu,
+			CoreId:         cpu,
+			ThreadSiblings: strconv.Itoa(cpu),
+		})
+	}
+	return cpuInfos
+}
+
+func TestParseUevent(t *testing.T) {
+	msg := "bind@/devices/pci0000:00/0000:00:01.0\x00ACTION=bind\x00DEVPATH=/devices/pci0000:00/0000:00:01.0\x00" +
+		"SUBSYSTEM=pci\x00DRIVER=vfio-pci\x00PCI_SLOT_NAME=0000:00:01.0\x00SEQNUM=4242\x00"
+	got, err := ParseUevent([]byte(msg))
+	if err != nil {
+		t.Fatalf("ParseUevent() error = %v", err)
+	}
+	want := Uevent{
+		Action:    "bind",
+		DevPath:   "/devices/pci0000:00/0000:00:01.0",
+		Subsystem: "pci",
+		Env: map[string]string{
+			"ACTION":        "bind",
+			"DEVPATH":       "/devices/pci0000:00/0000:00:01.0",
+			"SUBSYSTEM":     "pci",
+			"DRIVER":        "vfio-pci",
+			"PCI_SLOT_NAME": "0000:00:01.0",
+			"SEQNUM":        "4242",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseUevent() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseUevent([]byte("libudev\x00\xfe\xed")); err == nil {
+		t.Errorf("ParseUevent() error = nil, want error for a udev message")
+	}
+}
+
+func TestEventOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		uevent Uevent
+		want   Event
+		wantOk bool
+	}{
+		{
+			name:   "cpu offline",
+			uevent: Uevent{Action: "offline", DevPath: "/devices/system/cpu/cpu3", Subsystem: "cpu"},
+			want:   Event{Type: CPUOffline, CPU: 3},
+			wantOk: true,
+		},
+		{
+			name:   "cpu online",
+			uevent: Uevent{Action: "online", DevPath: "/devices/system/cpu/cpu12", Subsystem: "cpu"},
+			want:   Event{Type: CPUOnline, CPU: 12},
+			wantOk: true,
+		},
+		{
+			name:   "cpu change",
+			uevent: Uevent{Action: "change", DevPath: "/devices/system/cpu/cpu3", Subsystem: "cpu"},
+		},
+		{
+			name: "pci add",
+			uevent: Uevent{Action: "add", DevPath: "/devices/pci0000:80/0000:80:01.0/0000:81:00.2", Subsystem: "pci",
+				Env: map[string]string{}},
+			want:   Event{Type: PCIAdd, Address: "0000:81:00.2"},
+			wantOk: true,
+		},
+		{
+			name: "driver bind",
+			uevent: Uevent{Action: "bind", DevPath: "/devices/pci0000:80/0000:80:01.0/0000:81:00.2", Subsystem: "pci",
+				Env: map[string]string{"DRIVER": "vfio-pci", "PCI_SLOT_NAME": "0000:81:00.2"}},
+			want:   Event{Type: DriverBind, Address: "0000:81:00.2", Driver: "vfio-pci"},
+			wantOk: true,
+		},
+		{
+			name:   "resync",
+			uevent: Uevent{Action: ResyncAction},
+			want:   Event{Type: Resync},
+			wantOk: true,
+		},
+		{
+			name:   "other subsystem",
+			uevent: Uevent{Action: "add", DevPath: "/devices/virtual/net/veth0", Subsystem: "net"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := eventOf(tt.uevent)
+			if ok != tt.wantOk || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventOf() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	hostRoot := sysfstest.NewHostRoot(t)
+	addDevice(t, hostRoot, "0000:00:01.0")
+
+	onlineCPUs := []int{0, 1, 2, 3}
+	readCPUInfos := func() ([]cpuinfo.CPUInfo, error) {
+		return testCPUInfos(onlineCPUs...), nil
+	}
+
+	pciUevent := func(action, address string, env map[string]string) Uevent {
+		u := Uevent{Action: action, DevPath: "/devices/pci0000:00/" + address, Subsystem: "pci", Env: map[string]string{}}
+		for key, value := range env {
+			u.Env[key] = value
+		}
+		return u
+	}
+	newPath := filepath.Join(hostRoot, "sys/devices/pci0000:00/0000:00:02.0")
+	source := &fakeSource{
+		steps: []step{
+			{
+				// A scan would find CPU 4: the CPU map of a CPU going offline is
+				// updated without one.
+				prepare: func() { onlineCPUs = []int{0, 1, 2, 3, 4} },
+				uevent:  Uevent{Action: "offline", DevPath: "/devices/system/cpu/cpu1", Subsystem: "cpu"},
+			},
+			{
+				prepare: func() { onlineCPUs = []int{0, 1, 2, 3} },
+				uevent:  Uevent{Action: "online", DevPath: "/devices/system/cpu/cpu1", Subsystem: "cpu"},
+			},
+			{
+				prepare: func() { addDevice(t, hostRoot, "0000:00:02.0") },
+				uevent:  pciUevent("add", "0000:00:02.0", nil),
+			},
+			{
+				prepare: func() {
+					sysfstest.Symlink(t, hostRoot, "sys/bus/pci/drivers/vfio-pci", "sys/devices/pci0000:00/0000:00:02.0/driver")
+				},
+				uevent: pciUevent("bind", "0000:00:02.0", map[string]string{"DRIVER": "vfio-pci"}),
+			},
+			{
+				prepare: func() { remove(t, filepath.Join(newPath, "driver")) },
+				uevent:  pciUevent("unbind", "0000:00:02.0", nil),
+			},
+			{
+				prepare: func() { remove(t, filepath.Join(hostRoot, "sys/bus/pci/devices/0000:00:02.0")) },
+				uevent:  pciUevent("remove", "0000:00:02.0", nil),
+			},
+			{
+				// The uevents of these changes were lost.
+				prepare: func() {
+					onlineCPUs = []int{0, 1, 2}
+					addDevice(t, hostRoot, "0000:00:03.0")
+				},
+				uevent: Uevent{Action: ResyncAction},
+			},
+		},
+		applied: make(chan struct{}, 7),
+	}
+
+	w, err := NewWatcher(source, withCPUInfos(readCPUInfos))
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	if got := len(w.CPUMap().AbstractToMachine); got != 4 {
+		t.Fatalf("CPUMap() = %d abstract CPUs, want 4", got)
+	}
+
+	type observation struct {
+		event        string
+		driver       string
+		abstractCPUs int
+		devices      int
+	}
+	got := []observation{}
+	err = w.Run(context.Background(), func(event Event) {
+		o := observation{
+			event:        event.String(),
+			abstractCPUs: len(w.CPUMap().AbstractToMachine),
+			devices:      len(w.PCIEInfo().GetAllDevices()),
+		}
+		if event.Device != nil {
+			o.driver = event.Device.Driver
+		}
+		got = append(got, o)
+		source.applied <- struct{}{}
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []observation{
+		{event: "cpu-offline cpu=1", abstractCPUs: 3, devices: 1},
+		{event: "cpu-online cpu=1", abstractCPUs: 4, devices: 1},
+		{event: "pci-add 0000:00:02.0", abstractCPUs: 4, devices: 2},
+		{event: "driver-bind 0000:00:02.0 vfio-pci", driver: "vfio-pci", abstractCPUs: 4, devices: 2},
+		{event: "driver-unbind 0000:00:02.0 vfio-pci", abstractCPUs: 4, devices: 2},
+		{event: "pci-remove 0000:00:02.0", abstractCPUs: 4, devices: 1},
+		{event: "resync", abstractCPUs: 3, devices: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() observed %+v, want %+v", got, want)
+	}
+}